@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheOptions controls the on-disk template cache.
+type CacheOptions struct {
+	Disabled bool // never read or write the cache
+	Refresh  bool // ignore cached entries but store fresh ones
+}
+
+// templateKey identifies one version of a stack's template. A stack's
+// template only changes through an operation, which bumps LastUpdatedTime.
+type templateKey struct {
+	StackID string
+	Version time.Time
+}
+
+// templateKeyFor returns the cache key for a stack, or nil when its template
+// must not be cached: no ID, or an operation is still in progress.
+func templateKeyFor(stackID *string, created, updated *time.Time, status types.StackStatus) *templateKey {
+	if getValue(stackID) == "" || strings.HasSuffix(string(status), "_IN_PROGRESS") {
+		return nil
+	}
+	key := &templateKey{StackID: *stackID}
+	switch {
+	case updated != nil:
+		key.Version = *updated
+	case created != nil:
+		key.Version = *created
+	default:
+		return nil
+	}
+	return key
+}
+
+// cachedTemplate is the file format of a cache entry.
+type cachedTemplate struct {
+	StackId      string
+	StackName    string
+	Version      time.Time
+	CachedAt     time.Time
+	TemplateBody string
+}
+
+// cacheDir is $XDG_CACHE_HOME/cfn, falling back to the OS user cache directory.
+func cacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "cfn"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cfn"), nil
+}
+
+func templateCacheDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+func (k templateKey) fileName() string {
+	sum := sha256.Sum256([]byte(k.StackID + "\n" + k.Version.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+func readCachedTemplate(key templateKey) (string, bool) {
+	dir, err := templateCacheDir()
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key.fileName()))
+	if err != nil {
+		return "", false
+	}
+	var entry cachedTemplate
+	if err := json.Unmarshal(data, &entry); err != nil || entry.StackId != key.StackID || !entry.Version.Equal(key.Version) {
+		return "", false
+	}
+	return entry.TemplateBody, true
+}
+
+// writeCachedTemplate stores a template. The cache is best effort, so
+// failures are returned for tests but ignored by callers.
+func writeCachedTemplate(key templateKey, stackName, body string) error {
+	dir, err := templateCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cachedTemplate{
+		StackId:      key.StackID,
+		StackName:    stackName,
+		Version:      key.Version,
+		CachedAt:     time.Now().UTC(),
+		TemplateBody: body,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so concurrent readers never see a partial file
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, key.fileName()))
+}
+
+// getTemplateBody returns a stack's original template, from the cache when
+// key is set and the cache is enabled.
+func getTemplateBody(ctx context.Context, client cloudFormationAPI, stackName string, key *templateKey) (string, error) {
+	useCache := key != nil && !cacheOptions.Disabled
+	if useCache && !cacheOptions.Refresh {
+		if body, ok := readCachedTemplate(*key); ok {
+			return body, nil
+		}
+	}
+
+	output, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
+		StackName:     &stackName,
+		TemplateStage: types.TemplateStageOriginal,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body := getValue(output.TemplateBody)
+	if useCache && body != "" {
+		_ = writeCachedTemplate(*key, stackName, body)
+	}
+	return body, nil
+}
+
+// cacheStats is the structured (--output json|yaml) form of `cache stats`.
+type cacheStats struct {
+	Directory  string
+	Entries    int
+	TotalBytes int64
+	Oldest     *time.Time `json:",omitempty"`
+	Newest     *time.Time `json:",omitempty"`
+}
+
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect or prune the local template cache",
+		Long: `Inspect or prune the local template cache.
+
+Templates fetched by resource searches (list --type/--resource-name/--property)
+and by the template command are cached under $XDG_CACHE_HOME/cfn, keyed by
+stack ID and last update time, so unchanged stacks are never fetched twice.
+Use --no-cache to bypass the cache or --refresh to refetch and update it.`,
+	}
+
+	var olderThan string
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached templates",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("--older-than: %w", err)
+			}
+			return runCachePrune(age)
+		},
+	}
+	prune.Flags().StringVar(&olderThan, "older-than", "0", "Only remove entries cached longer ago than this (e.g. 30d, 720h); 0 removes everything")
+
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "Show template cache statistics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheStats()
+		},
+	}
+
+	cmd.AddCommand(prune, stats)
+	return cmd
+}
+
+// cacheEntries lists the cache files with their modification time, which is
+// the time they were cached.
+func cacheEntries() (string, []os.FileInfo, error) {
+	dir, err := templateCacheDir()
+	if err != nil {
+		return "", nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return dir, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var files []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+	}
+	return dir, files, nil
+}
+
+func runCacheStats() error {
+	dir, files, err := cacheEntries()
+	if err != nil {
+		return err
+	}
+
+	stats := cacheStats{Directory: dir, Entries: len(files)}
+	for _, f := range files {
+		stats.TotalBytes += f.Size()
+		mod := f.ModTime()
+		if stats.Oldest == nil || mod.Before(*stats.Oldest) {
+			stats.Oldest = &mod
+		}
+		if stats.Newest == nil || mod.After(*stats.Newest) {
+			stats.Newest = &mod
+		}
+	}
+
+	if isObjectOutput() {
+		return printObject(stats)
+	}
+
+	table := makeTable([]string{"DIRECTORY", "ENTRIES", "SIZE", "OLDEST", "NEWEST"})
+	table.Rows = append(table.Rows, v1.TableRow{Cells: []interface{}{
+		stats.Directory,
+		fmt.Sprintf("%d", stats.Entries),
+		formatBytes(stats.TotalBytes),
+		formatTime(stats.Oldest),
+		formatTime(stats.Newest),
+	}})
+	return printTable(table)
+}
+
+func runCachePrune(olderThan time.Duration) error {
+	dir, files, err := cacheEntries()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	var freed int64
+	for _, f := range files {
+		if olderThan > 0 && f.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+		freed += f.Size()
+	}
+
+	fmt.Printf("Removed %d cached templates (%s)\n", removed, formatBytes(freed))
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestTemplateKeyFor(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	id := aws.String("arn:aws:cloudformation:us-east-1:111111111111:stack/a/1")
+
+	if key := templateKeyFor(id, &created, &updated, types.StackStatusUpdateComplete); key == nil || !key.Version.Equal(updated) {
+		t.Errorf("updated stack: got %+v", key)
+	}
+	if key := templateKeyFor(id, &created, nil, types.StackStatusCreateComplete); key == nil || !key.Version.Equal(created) {
+		t.Errorf("never updated stack: got %+v", key)
+	}
+	if key := templateKeyFor(id, &created, &updated, types.StackStatusUpdateInProgress); key != nil {
+		t.Errorf("in-progress stack should not be cached: got %+v", key)
+	}
+	if key := templateKeyFor(nil, &created, nil, types.StackStatusCreateComplete); key != nil {
+		t.Errorf("stack without ID should not be cached: got %+v", key)
+	}
+
+	other := templateKey{StackID: *id, Version: created}
+	if (templateKey{StackID: *id, Version: updated}).fileName() == other.fileName() {
+		t.Error("different versions must use different cache files")
+	}
+}
+
+// runSearch runs `cfn list --type AWS::SQS::Queue -o name` against the fake.
+func runSearch(t *testing.T, cache CacheOptions) string {
+	t.Helper()
+	if err := SetGlobalFlags("", false, "name", "", AWSOptions{}, cache); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetGlobalFlags("", false, "", "", AWSOptions{}, CacheOptions{}) })
+
+	cmd := ListCmd()
+	resourceType = "AWS::SQS::Queue"
+	t.Cleanup(func() {
+		resourceType = ""
+		namesOnly = false
+	})
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, nil) })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	return out
+}
+
+func TestResourceSearchUsesCache(t *testing.T) {
+	client := newFakeClient(t)
+
+	first := runSearch(t, CacheOptions{})
+	if client.calls["GetTemplate"] != 5 {
+		t.Fatalf("first search: %d GetTemplate calls, want 5", client.calls["GetTemplate"])
+	}
+
+	second := runSearch(t, CacheOptions{})
+	if first != second {
+		t.Errorf("cached search returned %q, want %q", second, first)
+	}
+	// Only the in-progress stack is fetched again
+	if got := client.calls["GetTemplate"] - 5; got != 1 {
+		t.Errorf("second search: %d GetTemplate calls, want 1", got)
+	}
+
+	runSearch(t, CacheOptions{Refresh: true})
+	if got := client.calls["GetTemplate"] - 6; got != 5 {
+		t.Errorf("refresh: %d GetTemplate calls, want 5", got)
+	}
+}
+
+func TestResourceSearchNoCache(t *testing.T) {
+	client := newFakeClient(t)
+
+	runSearch(t, CacheOptions{Disabled: true})
+	runSearch(t, CacheOptions{Disabled: true})
+	if client.calls["GetTemplate"] != 10 {
+		t.Errorf("%d GetTemplate calls, want 10", client.calls["GetTemplate"])
+	}
+
+	dir, files, err := cacheEntries()
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected an empty cache in %s, got %d entries (err %v)", dir, len(files), err)
+	}
+}
+
+func TestTemplateCommandUsesCache(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "")
+
+	for i := 0; i < 2; i++ {
+		var err error
+		out := captureStdout(t, func() { err = runTemplate("prod-app-api", false) })
+		if err != nil {
+			t.Fatalf("runTemplate: %v", err)
+		}
+		if out != client.templates["prod-app-api"] {
+			t.Fatalf("unexpected template output:\n%s", out)
+		}
+	}
+	if client.calls["GetTemplate"] != 1 {
+		t.Errorf("%d GetTemplate calls, want 1", client.calls["GetTemplate"])
+	}
+}
+
+func TestCacheStatsAndPrune(t *testing.T) {
+	newFakeClient(t)
+	runSearch(t, CacheOptions{})
+
+	setOutput(t, "json")
+	var err error
+	out := captureStdout(t, func() { err = runCacheStats() })
+	if err != nil {
+		t.Fatalf("runCacheStats: %v", err)
+	}
+	var stats cacheStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	// Every stack except the in-progress one
+	if stats.Entries != 4 || stats.TotalBytes == 0 || !strings.HasSuffix(stats.Directory, filepath.Join("cfn", "templates")) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Entries cached just now survive an age-based prune
+	captureStdout(t, func() { err = runCachePrune(time.Hour) })
+	if _, files, _ := cacheEntries(); err != nil || len(files) != 4 {
+		t.Errorf("prune --older-than 1h removed fresh entries (%d left, err %v)", len(files), err)
+	}
+
+	out = captureStdout(t, func() { err = runCachePrune(0) })
+	if err != nil || !strings.HasPrefix(out, "Removed 4 cached templates") {
+		t.Errorf("unexpected prune output %q (err %v)", out, err)
+	}
+	dir, files, _ := cacheEntries()
+	if len(files) != 0 {
+		t.Errorf("expected %s to be empty, got %d entries", dir, len(files))
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("prune should keep the cache directory: %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
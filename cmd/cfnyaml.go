@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTemplateYAML decodes a YAML template into the same shape as its JSON
+// form. CloudFormation short-form tags are rewritten to their long form, so
+// "!Ref Vpc" becomes {"Ref": "Vpc"} and "!GetAtt Role.Arn" becomes
+// {"Fn::GetAtt": ["Role", "Arn"]}.
+func decodeTemplateYAML(body []byte) (map[string]interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+
+	value, err := convertYAMLNode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	template, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("template is not a mapping")
+	}
+	return template, nil
+}
+
+func convertYAMLNode(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return convertYAMLNode(node.Content[0])
+	case yaml.AliasNode:
+		return convertYAMLNode(node.Alias)
+	}
+
+	if fn, ok := intrinsicTag(node.Tag); ok {
+		return convertIntrinsic(fn, node)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				if err := mergeYAMLMapping(m, value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v, err := convertYAMLNode(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key.Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := convertYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	default:
+		// Unquoted dates such as AWSTemplateFormatVersion stay strings, as
+		// CloudFormation reads them
+		if node.ShortTag() == "!!timestamp" {
+			return node.Value, nil
+		}
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return v, nil
+	}
+}
+
+// mergeYAMLMapping applies a "<<" merge key; keys already set take precedence.
+func mergeYAMLMapping(m map[string]interface{}, node *yaml.Node) error {
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+	for _, src := range sources {
+		v, err := convertYAMLNode(src)
+		if err != nil {
+			return err
+		}
+		merged, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("line %d: merge value is not a mapping", src.Line)
+		}
+		for k, val := range merged {
+			if _, exists := m[k]; !exists {
+				m[k] = val
+			}
+		}
+	}
+	return nil
+}
+
+// intrinsicTag maps a short-form tag to its long-form function name: "!Ref"
+// and "!Condition" keep their name, everything else gains the "Fn::" prefix.
+// Standard YAML tags ("!!str", "!!int", ...) are not intrinsics.
+func intrinsicTag(tag string) (string, bool) {
+	if !strings.HasPrefix(tag, "!") || strings.HasPrefix(tag, "!!") || len(tag) < 2 {
+		return "", false
+	}
+	name := tag[1:]
+	switch name {
+	case "Ref", "Condition":
+		return name, true
+	}
+	return "Fn::" + name, true
+}
+
+func convertIntrinsic(fn string, node *yaml.Node) (interface{}, error) {
+	var arg interface{}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		// Arguments of short-form scalars are always strings
+		arg = node.Value
+		if fn == "Fn::GetAtt" {
+			// !GetAtt Resource.Attribute; the attribute may itself contain dots
+			parts := strings.SplitN(node.Value, ".", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: !GetAtt %q must be Resource.Attribute", node.Line, node.Value)
+			}
+			arg = []interface{}{parts[0], parts[1]}
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		// Convert the content as an untagged node of the same kind
+		untagged := *node
+		untagged.Tag = ""
+		v, err := convertYAMLNode(&untagged)
+		if err != nil {
+			return nil, err
+		}
+		arg = v
+	default:
+		return nil, fmt.Errorf("line %d: unsupported value for %s", node.Line, node.Tag)
+	}
+	return map[string]interface{}{fn: arg}, nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeTemplateYAMLShortForm(t *testing.T) {
+	body := `
+AWSTemplateFormatVersion: 2010-09-09
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${AWS::StackName}-logs"
+      Ref: !Ref Vpc
+      Arn: !GetAtt Role.Outputs.Arn
+      ArnList: !GetAtt [Role, Arn]
+      Az: !Select [0, !GetAZs ""]
+      Joined: !Join ["-", [a, !Ref b]]
+      SubMap: !Sub
+        - "${Name}-x"
+        - Name: !Ref Name
+      Cond: !If [IsProd, !Ref AWS::NoValue, 3]
+      Import: !ImportValue shared-vpc
+      Count: 3
+      Enabled: true
+      Quoted: "3"
+`
+	got, err := decodeTemplateYAML([]byte(body))
+	if err != nil {
+		t.Fatalf("decodeTemplateYAML: %v", err)
+	}
+
+	if v := got["AWSTemplateFormatVersion"]; v != "2010-09-09" {
+		t.Errorf("AWSTemplateFormatVersion = %#v, want string", v)
+	}
+
+	props := got["Resources"].(map[string]interface{})["Bucket"].(map[string]interface{})["Properties"].(map[string]interface{})
+	want := map[string]interface{}{
+		"BucketName": map[string]interface{}{"Fn::Sub": "${AWS::StackName}-logs"},
+		"Ref":        map[string]interface{}{"Ref": "Vpc"},
+		"Arn":        map[string]interface{}{"Fn::GetAtt": []interface{}{"Role", "Outputs.Arn"}},
+		"ArnList":    map[string]interface{}{"Fn::GetAtt": []interface{}{"Role", "Arn"}},
+		"Az": map[string]interface{}{"Fn::Select": []interface{}{
+			0, map[string]interface{}{"Fn::GetAZs": ""},
+		}},
+		"Joined": map[string]interface{}{"Fn::Join": []interface{}{
+			"-", []interface{}{"a", map[string]interface{}{"Ref": "b"}},
+		}},
+		"SubMap": map[string]interface{}{"Fn::Sub": []interface{}{
+			"${Name}-x", map[string]interface{}{"Name": map[string]interface{}{"Ref": "Name"}},
+		}},
+		"Cond": map[string]interface{}{"Fn::If": []interface{}{
+			"IsProd", map[string]interface{}{"Ref": "AWS::NoValue"}, 3,
+		}},
+		"Import":  map[string]interface{}{"Fn::ImportValue": "shared-vpc"},
+		"Count":   3,
+		"Enabled": true,
+		"Quoted":  "3",
+	}
+	for k, w := range want {
+		if !reflect.DeepEqual(props[k], w) {
+			t.Errorf("%s = %#v, want %#v", k, props[k], w)
+		}
+	}
+}
+
+func TestDecodeTemplateYAMLAnchors(t *testing.T) {
+	body := `
+Defaults: &defaults
+  Runtime: python3.12
+  MemorySize: 128
+Resources:
+  Fn:
+    Type: AWS::Lambda::Function
+    Properties:
+      <<: *defaults
+      MemorySize: 256
+`
+	got, err := decodeTemplateYAML([]byte(body))
+	if err != nil {
+		t.Fatalf("decodeTemplateYAML: %v", err)
+	}
+	props := got["Resources"].(map[string]interface{})["Fn"].(map[string]interface{})["Properties"]
+	want := map[string]interface{}{"Runtime": "python3.12", "MemorySize": 256}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("got %#v, want %#v", props, want)
+	}
+}
+
+func TestDecodeTemplateYAMLErrors(t *testing.T) {
+	for _, body := range []string{
+		"",
+		"- a\n- b\n",
+		"Value: !GetAtt NoAttribute\n",
+		"Value: [unclosed\n",
+	} {
+		if _, err := decodeTemplateYAML([]byte(body)); err == nil {
+			t.Errorf("expected an error for %q", body)
+		}
+	}
+}
+
+func TestSearchShortFormTemplate(t *testing.T) {
+	client := newFakeClient(t)
+
+	for _, expr := range []string{
+		"QueueName=${AWS::StackName}-queue",
+		"RedrivePolicy.deadLetterTargetArn=shared-dlq-arn",
+		"Tags[*].Value=Environment",
+		"VisibilityTimeout.Fn::If[1]>=300",
+	} {
+		f, err := parsePropertyFilter(expr, false)
+		if err != nil {
+			t.Fatalf("parsePropertyFilter(%q): %v", expr, err)
+		}
+		matches, err := findStackMatches(context.Background(), client, summaryNamed("prod-app-worker"), "AWS::SQS::Queue", "", []propertyFilter{f}, false, false)
+		if err != nil {
+			t.Fatalf("findStackMatches(%q): %v", expr, err)
+		}
+		if len(matches) == 0 {
+			t.Errorf("expected %q to match the short-form template", expr)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// cloudFormationAPI is the subset of the CloudFormation client used by the
+// commands. It is satisfied by *cloudformation.Client and by the in-memory
+// fake used in tests, and is compatible with the SDK paginators.
+type cloudFormationAPI interface {
+	cloudformation.ListStacksAPIClient
+	cloudformation.DescribeStacksAPIClient
+	cloudformation.DescribeStackEventsAPIClient
+	cloudformation.ListStackResourcesAPIClient
+	cloudformation.DescribeStackResourceDriftsAPIClient
+	GetTemplate(ctx context.Context, params *cloudformation.GetTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error)
+	DetectStackDrift(ctx context.Context, params *cloudformation.DetectStackDriftInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DetectStackDriftOutput, error)
+	DescribeStackDriftDetectionStatus(ctx context.Context, params *cloudformation.DescribeStackDriftDetectionStatusInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error)
+	ValidateTemplate(ctx context.Context, params *cloudformation.ValidateTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.ValidateTemplateOutput, error)
+}
+
+// AWSOptions selects the credentials and endpoint used to reach CloudFormation.
+// The zero value uses the SDK's default credential chain.
+type AWSOptions struct {
+	Profile     string // named profile from the shared config files
+	RoleARN     string // role to assume on top of the base credentials
+	ExternalID  string // external ID passed when assuming RoleARN
+	SessionName string // role session name, defaults to the SDK's generated one
+	MFASerial   string // MFA device serial or ARN; prompts for a token code
+	EndpointURL string // custom CloudFormation endpoint, e.g. LocalStack
+}
+
+func (o AWSOptions) validate() error {
+	if o.RoleARN == "" {
+		switch {
+		case o.ExternalID != "":
+			return fmt.Errorf("--external-id requires --role-arn")
+		case o.SessionName != "":
+			return fmt.Errorf("--session-name requires --role-arn")
+		case o.MFASerial != "":
+			return fmt.Errorf("--mfa-serial requires --role-arn")
+		}
+	}
+	return nil
+}
+
+// target is an account (through a named profile) and region to query.
+// Empty fields fall back to the SDK defaults.
+type target struct {
+	profile string
+	region  string
+}
+
+func (t target) String() string {
+	s := t.region
+	if s == "" {
+		s = "default region"
+	}
+	if t.profile != "" {
+		s = t.profile + "/" + s
+	}
+	return s
+}
+
+// defaultTarget is the account and region selected by the global flags.
+func defaultTarget() target {
+	return target{profile: awsOptions.Profile, region: region}
+}
+
+// newClient builds the client for a target. Tests replace it to hand out a
+// fake instead of talking to AWS.
+var newClient = func(ctx context.Context, t target) (cloudFormationAPI, error) {
+	opts := awsOptions
+	opts.Profile = t.profile
+	cfg, err := sharedAWSConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if t.region != "" {
+		cfg.Region = t.region
+	}
+	return cloudformation.NewFromConfig(cfg, func(o *cloudformation.Options) {
+		if awsOptions.EndpointURL != "" {
+			o.BaseEndpoint = aws.String(awsOptions.EndpointURL)
+		}
+	}), nil
+}
+
+func getClient(ctx context.Context) (cloudFormationAPI, error) {
+	return getTargetClient(ctx, defaultTarget())
+}
+
+func getTargetClient(ctx context.Context, t target) (cloudFormationAPI, error) {
+	client, err := newClient(ctx, t)
+	if err != nil {
+		return nil, withExitCode(exitAuthError, fmt.Errorf("failed to load AWS config: %w", err))
+	}
+	return client, nil
+}
+
+// maxRetryAttempts is generous so throttled requests are retried rather than
+// reported as failures.
+const maxRetryAttempts = 10
+
+var (
+	configCacheMu sync.Mutex
+	configCache   = make(map[AWSOptions]aws.Config)
+)
+
+// sharedAWSConfig loads the config for a set of options once, so targets that
+// only differ by region share one credentials cache (and one MFA prompt).
+func sharedAWSConfig(ctx context.Context, opts AWSOptions) (aws.Config, error) {
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+
+	if cfg, ok := configCache[opts]; ok {
+		return cfg.Copy(), nil
+	}
+	cfg, err := loadAWSConfig(ctx, opts, region)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	configCache[opts] = cfg
+	return cfg.Copy(), nil
+}
+
+func loadAWSConfig(ctx context.Context, opts AWSOptions, region string) (aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, func(lo *config.LoadOptions) error {
+		if region != "" {
+			lo.Region = region
+		}
+		if opts.Profile != "" {
+			lo.SharedConfigProfile = opts.Profile
+		}
+		// Adaptive retries back off and rate limit the client when
+		// CloudFormation throttles, e.g. during concurrent template searches.
+		lo.Retryer = func() aws.Retryer {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+					so.MaxAttempts = maxRetryAttempts
+				})
+			})
+		}
+		// Profiles that set mfa_serial prompt for the token code
+		lo.AssumeRoleCredentialOptions = func(o *stscreds.AssumeRoleOptions) {
+			o.TokenProvider = promptMFAToken
+		}
+		return nil
+	})
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	if opts.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opts.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if opts.ExternalID != "" {
+				o.ExternalID = aws.String(opts.ExternalID)
+			}
+			if opts.SessionName != "" {
+				o.RoleSessionName = opts.SessionName
+			}
+			if opts.MFASerial != "" {
+				o.SerialNumber = aws.String(opts.MFASerial)
+				o.TokenProvider = promptMFAToken
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg, nil
+}
+
+var mfaPromptMu sync.Mutex
+
+// promptMFAToken reads an MFA token code from stdin. Unlike
+// stscreds.StdinTokenProvider it prompts on stderr, so it never mixes with
+// structured output, and it serialises concurrent prompts.
+func promptMFAToken() (string, error) {
+	mfaPromptMu.Lock()
+	defer mfaPromptMu.Unlock()
+
+	fmt.Fprint(os.Stderr, "MFA token code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read MFA token code: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
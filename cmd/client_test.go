@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func TestAWSOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AWSOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: AWSOptions{}},
+		{name: "profile only", opts: AWSOptions{Profile: "prod"}},
+		{name: "role with extras", opts: AWSOptions{RoleARN: "arn:aws:iam::111111111111:role/ops", ExternalID: "x", SessionName: "me", MFASerial: "arn:aws:iam::111111111111:mfa/me"}},
+		{name: "external id without role", opts: AWSOptions{ExternalID: "x"}, wantErr: true},
+		{name: "session name without role", opts: AWSOptions{SessionName: "me"}, wantErr: true},
+		{name: "mfa serial without role", opts: AWSOptions{MFASerial: "arn:aws:iam::111111111111:mfa/me"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// isolateAWSConfig points the SDK at an empty environment so tests never pick
+// up the developer's own credentials.
+func isolateAWSConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, key := range []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	return dir
+}
+
+func TestLoadAWSConfigProfile(t *testing.T) {
+	dir := isolateAWSConfig(t)
+	config := "[profile staging]\nregion = eu-west-1\naws_access_key_id = AKIDSTAGING\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadAWSConfig(context.Background(), AWSOptions{Profile: "staging"}, "")
+	if err != nil {
+		t.Fatalf("loadAWSConfig: %v", err)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("region = %q, want eu-west-1", cfg.Region)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if creds.AccessKeyID != "AKIDSTAGING" {
+		t.Errorf("access key = %q, want AKIDSTAGING", creds.AccessKeyID)
+	}
+
+	if _, err := loadAWSConfig(context.Background(), AWSOptions{Profile: "missing"}, ""); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestEndpointURL(t *testing.T) {
+	isolateAWSConfig(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<ListStacksResponse xmlns="http://cloudformation.amazonaws.com/doc/2010-05-15/">
+  <ListStacksResult>
+    <StackSummaries>
+      <member>
+        <StackName>local-stack</StackName>
+        <StackId>arn:aws:cloudformation:us-east-1:000000000000:stack/local-stack/1</StackId>
+        <StackStatus>CREATE_COMPLETE</StackStatus>
+        <CreationTime>2024-01-01T00:00:00Z</CreationTime>
+      </member>
+    </StackSummaries>
+  </ListStacksResult>
+</ListStacksResponse>`))
+	}))
+	defer server.Close()
+
+	setOutput(t, "")
+	awsOptions = AWSOptions{EndpointURL: server.URL}
+
+	client, err := getClient(context.Background())
+	if err != nil {
+		t.Fatalf("getClient: %v", err)
+	}
+	out, err := client.ListStacks(context.Background(), &cloudformation.ListStacksInput{})
+	if err != nil {
+		t.Fatalf("ListStacks: %v", err)
+	}
+	if requests != 1 || len(out.StackSummaries) != 1 || getValue(out.StackSummaries[0].StackName) != "local-stack" {
+		t.Errorf("unexpected response from local endpoint (%d requests): %+v", requests, out.StackSummaries)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ANSI colours used to highlight statuses.
+const (
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiMagenta = "\033[35m"
+	ansiReset   = "\033[0m"
+)
+
+// Values of --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// colorMode is the --color flag; empty means auto.
+var colorMode string
+
+func parseColorMode(mode string) (string, error) {
+	switch mode {
+	case "", colorAuto:
+		return colorAuto, nil
+	case colorAlways, colorNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --color %q, expected always, never or auto", mode)
+}
+
+// colorEnabled reports whether output written to f should be coloured: always
+// or never with --color, and by default when f is a terminal and NO_COLOR
+// (https://no-color.org) is not set.
+func colorEnabled(f *os.File) bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+func colorize(s, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// statusColor returns the colour of a stack or resource status: red when it
+// failed, magenta while or after rolling back, yellow in progress and green
+// complete. Other statuses, such as DELETE_SKIPPED, are not coloured.
+func statusColor(status string) string {
+	switch {
+	case strings.HasSuffix(status, "_FAILED"):
+		return ansiRed
+	case strings.Contains(status, "ROLLBACK"):
+		return ansiMagenta
+	case strings.HasSuffix(status, "_IN_PROGRESS"):
+		return ansiYellow
+	case strings.HasSuffix(status, "_COMPLETE"):
+		return ansiGreen
+	}
+	return ""
+}
+
+// terminalWidth returns the number of columns of the terminal f is attached
+// to, falling back to $COLUMNS, or 0 when unknown or not a terminal.
+func terminalWidth(f *os.File) int {
+	w, _ := terminalSize(f)
+	return w
+}
+
+// terminalSize returns the columns and lines of the terminal f is attached
+// to, each falling back to $COLUMNS and $LINES. Both are 0 when f is not a
+// terminal: output piped to a file or another command is not cut to the
+// width of the shell it was started from.
+func terminalSize(f *os.File) (width, height int) {
+	if !isTerminal(f) {
+		return 0, 0
+	}
+	width, height = ttySize(f)
+	if width <= 0 {
+		width = envSize("COLUMNS")
+	}
+	if height <= 0 {
+		height = envSize("LINES")
+	}
+	return width, height
+}
+
+func envSize(name string) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// stackColors are the colours stacks are told apart by in a merged stream.
+// Red, green, yellow and magenta are left out as they read as statuses.
+var stackColors = []string{
+	"\033[36m", "\033[34m", "\033[96m", "\033[94m",
+}
+
+// stackPalette hands out stackColors to stacks in order of appearance,
+// cycling through them when there are more stacks than colours.
+type stackPalette struct {
+	assigned map[string]string
+}
+
+func (p *stackPalette) color(stack string) string {
+	if p.assigned == nil {
+		p.assigned = make(map[string]string)
+	}
+	c, ok := p.assigned[stack]
+	if !ok {
+		c = stackColors[len(p.assigned)%len(stackColors)]
+		p.assigned[stack] = c
+	}
+	return c
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+var ansiEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func setColor(t *testing.T, mode string) {
+	t.Helper()
+	colorMode = mode
+	t.Cleanup(func() { colorMode = "" })
+}
+
+func TestStatusColor(t *testing.T) {
+	for status, want := range map[string]string{
+		"CREATE_COMPLETE":             ansiGreen,
+		"UPDATE_IN_PROGRESS":          ansiYellow,
+		"UPDATE_FAILED":               ansiRed,
+		"UPDATE_ROLLBACK_FAILED":      ansiRed,
+		"ROLLBACK_IN_PROGRESS":        ansiMagenta,
+		"UPDATE_ROLLBACK_COMPLETE":    ansiMagenta,
+		"DELETE_SKIPPED":              "",
+		"IMPORT_ROLLBACK_IN_PROGRESS": ansiMagenta,
+	} {
+		if got := statusColor(status); got != want {
+			t.Errorf("statusColor(%s) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// Test output is not a terminal
+	if colorEnabled(os.Stdout) {
+		t.Error("expected no colour by default")
+	}
+	setColor(t, colorAlways)
+	t.Setenv("NO_COLOR", "1")
+	if !colorEnabled(os.Stdout) {
+		t.Error("expected --color=always to colour regardless")
+	}
+	setColor(t, colorNever)
+	if colorEnabled(os.Stdout) {
+		t.Error("expected --color=never not to colour")
+	}
+
+	if err := SetGlobalFlags("", false, "", "sometimes", AWSOptions{}, CacheOptions{}); err == nil {
+		t.Error("expected an invalid --color to fail")
+	}
+}
+
+func TestTerminalSizeNotTerminal(t *testing.T) {
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("LINES", "24")
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Output to a file or pipe is never cut to the shell's width
+	if width, height := terminalSize(f); width != 0 || height != 0 {
+		t.Errorf("got %dx%d, want 0x0", width, height)
+	}
+}
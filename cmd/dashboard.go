@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// dashboardEvents is how many of the latest events the dashboard shows.
+const dashboardEvents = 5
+
+// Escape sequences that switch to the terminal's alternate screen with the
+// cursor hidden, and back.
+const (
+	enterFullScreen = "\033[?1049h\033[?25l"
+	leaveFullScreen = "\033[?25h\033[?1049l"
+)
+
+// dashboard is the state shown by `cfn tail --dashboard`: every resource of
+// one stack, the progress of the current operation and the latest events.
+type dashboard struct {
+	stackName   string
+	stackStatus string
+	operation   string            // e.g. "update"
+	start       *time.Time        // start of the current operation
+	total       int               // resources in the processed template, 0 when unknown
+	touched     map[string]string // latest status of the resources with events in the current operation
+	resources   []types.StackResourceSummary
+	recent      []types.StackEvent // oldest first
+	err         error              // the last failure to refresh
+}
+
+// runDashboard redraws the dashboard of the single stack being tailed on
+// every poll, until interrupted or, with --until-complete, until the
+// operation finishes. The last frame is left on the screen.
+func (t *tailer) runDashboard(ctx context.Context) error {
+	s := t.stacks[0]
+	d := &dashboard{stackName: stackNameFromID(s.name), stackStatus: s.status}
+
+	// The current operation's events give its start and the latest events
+	events, err := listEventsUntil(ctx, t.client, s.name, isOperationStart)
+	if err != nil {
+		return fmt.Errorf("failed to get initial events: %w", err)
+	}
+	sortEvents(events, false)
+	d.record(events)
+	d.refresh(ctx, t.client, s.name, true)
+
+	fmt.Print(enterFullScreen)
+	screen := true
+	leave := func() {
+		if screen {
+			fmt.Print(leaveFullScreen)
+			screen = false
+		}
+	}
+	defer leave()
+	draw := func() {
+		width, height := terminalSize(os.Stdout)
+		var buf bytes.Buffer
+		d.render(&buf, width, height, colorEnabled(os.Stdout))
+		// Overwrite the previous frame in place rather than clearing the
+		// screen, which flickers
+		fmt.Print("\033[H", strings.ReplaceAll(buf.String(), "\n", "\033[K\n"), "\033[J")
+	}
+	// The last frame stays on the normal screen once the dashboard exits
+	final := func() {
+		leave()
+		width, _ := terminalSize(os.Stdout)
+		d.render(os.Stdout, width, 0, colorEnabled(os.Stdout))
+	}
+	draw()
+
+	timer := time.NewTimer(t.pollInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			final()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return t.timedOut()
+			}
+			fmt.Fprintln(os.Stderr, "\nStopped.")
+			return nil
+		case <-timer.C:
+			events := t.poll(ctx)
+			started := d.record(events)
+			d.refresh(ctx, t.client, s.name, started)
+			if t.opts.untilComplete && t.allDone() {
+				final()
+				return t.finish()
+			}
+			draw()
+			timer.Reset(t.pollInterval())
+		}
+	}
+}
+
+// record adds new events, oldest first, and reports whether one of them
+// started a new operation.
+func (d *dashboard) record(events []types.StackEvent) bool {
+	started := false
+	for _, e := range events {
+		if !isStackEvent(e) {
+			if d.touched != nil {
+				d.touched[getValue(e.LogicalResourceId)] = string(e.ResourceStatus)
+			}
+			continue
+		}
+		d.stackStatus = string(e.ResourceStatus)
+		if isOperationStart(e) {
+			d.operation = operationKind(e)
+			d.start = e.Timestamp
+			d.touched = make(map[string]string)
+			started = true
+		}
+	}
+	d.recent = append(d.recent, events...)
+	if n := len(d.recent); n > dashboardEvents {
+		d.recent = d.recent[n-dashboardEvents:]
+	}
+	return started
+}
+
+// refresh lists the stack's resources, and counts those in its template when
+// a new operation may have brought a new one. Failures are shown on the
+// dashboard rather than ending it.
+func (d *dashboard) refresh(ctx context.Context, client cloudFormationAPI, stackName string, template bool) {
+	resources, err := listStackResources(ctx, client, stackName)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.err = fmt.Errorf("failed to list resources: %w", err)
+		}
+		return
+	}
+	d.resources = resources
+	d.err = nil
+
+	if !template {
+		return
+	}
+	// The processed template has a Transform's resources expanded, e.g. the
+	// function, role and permissions of an AWS::Serverless::Function
+	output, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
+		StackName:     &stackName,
+		TemplateStage: types.TemplateStageProcessed,
+	})
+	if err != nil {
+		d.err = fmt.Errorf("failed to get template: %w", err)
+		return
+	}
+	parsed, err := parseTemplate(getValue(output.TemplateBody))
+	if err != nil {
+		d.err = err
+		return
+	}
+	d.total = 0
+	if r, ok := parsed["Resources"].(map[string]interface{}); ok {
+		d.total = len(r)
+	}
+}
+
+// inProgress reports whether the stack is in the middle of an operation.
+func (d *dashboard) inProgress() bool {
+	return strings.HasSuffix(d.stackStatus, "_IN_PROGRESS")
+}
+
+// progress returns how many resources are complete out of how many. While an
+// update (or any operation other than a create or delete) is in progress,
+// only the resources it has touched so far count, as the others are left
+// alone. Otherwise every resource counts, out of those in the template, which
+// is authoritative for the total as resources about to be removed are listed
+// until the end of an update.
+func (d *dashboard) progress() (done, total int) {
+	if d.inProgress() && d.touched != nil && d.operation != "create" && d.operation != "delete" {
+		for _, status := range d.touched {
+			if isCompleteStatus(status) {
+				done++
+			}
+		}
+		return done, len(d.touched)
+	}
+
+	for _, r := range d.resources {
+		if !isCompleteStatus(string(r.ResourceStatus)) {
+			continue
+		}
+		// Resources a delete hasn't reached yet keep their earlier status
+		if d.inProgress() && d.start != nil && (r.LastUpdatedTimestamp == nil || !r.LastUpdatedTimestamp.After(*d.start)) {
+			continue
+		}
+		done++
+	}
+	total = d.total
+	if total == 0 {
+		total = len(d.resources)
+	}
+	return min(done, total), total
+}
+
+// isCompleteStatus reports whether a resource is done with an operation.
+func isCompleteStatus(status string) bool {
+	return strings.HasSuffix(status, "_COMPLETE") || status == "DELETE_SKIPPED"
+}
+
+// statusRank orders resources on the dashboard: failures first, then
+// rollbacks and the ones in progress, and complete ones last.
+func statusRank(status string) int {
+	switch {
+	case strings.HasSuffix(status, "_FAILED"):
+		return 0
+	case strings.Contains(status, "ROLLBACK"):
+		return 1
+	case strings.HasSuffix(status, "_IN_PROGRESS"):
+		return 2
+	case strings.HasSuffix(status, "_COMPLETE"):
+		return 4
+	}
+	return 3
+}
+
+// render writes a frame of the dashboard for a terminal width columns wide
+// and height lines high, either of which is 0 when unknown. Resources that
+// don't fit are counted on the last line of their table.
+func (d *dashboard) render(w io.Writer, width, height int, color bool) {
+	status := colorize(d.stackStatus, statusColor(d.stackStatus), color)
+	fmt.Fprintf(w, "%s  %s", d.stackName, status)
+	if d.start != nil {
+		fmt.Fprintf(w, "  %s started %s (%s ago)", d.operation, formatTime(d.start), duration.HumanDuration(now().Sub(*d.start)))
+	}
+	fmt.Fprintln(w)
+
+	done, total := d.progress()
+	barWidth := 40
+	if width > 0 {
+		barWidth = max(min(barWidth, width-30), 10)
+	}
+	label := "resources complete"
+	if d.inProgress() && d.operation != "" {
+		label = "resources complete in this " + d.operation
+	}
+	fmt.Fprintf(w, "%s  %d/%d %s\n", progressBar(done, total, barWidth), done, total, label)
+	if d.err != nil {
+		fmt.Fprintln(w, colorize("warning: "+d.err.Error(), ansiRed, color))
+	}
+	fmt.Fprintln(w)
+
+	resources := append([]types.StackResourceSummary{}, d.resources...)
+	sort.SliceStable(resources, func(i, j int) bool {
+		ri, rj := statusRank(string(resources[i].ResourceStatus)), statusRank(string(resources[j].ResourceStatus))
+		if ri != rj {
+			return ri < rj
+		}
+		return getValue(resources[i].LogicalResourceId) < getValue(resources[j].LogicalResourceId)
+	})
+
+	// Leave room for the title, the progress bar, the table header and the
+	// latest events
+	rows, hidden := len(resources), 0
+	if height > 0 {
+		reserved := 4 + len(d.recent) + 2
+		if d.err != nil {
+			reserved++
+		}
+		if fit := max(height-reserved, 3); fit < rows {
+			rows = fit - 1 // for the line counting the others
+			hidden = len(resources) - rows
+		}
+	}
+
+	titles := []string{"RESOURCE", "TYPE", "STATUS", "FOR", "REASON"}
+	widths := []int{len(titles[0]), len(titles[1]), len(titles[2]), 8, 0}
+	for _, r := range resources[:rows] {
+		widths[0] = max(widths[0], min(len(getValue(r.LogicalResourceId)), 40))
+		widths[1] = max(widths[1], min(len(getValue(r.ResourceType)), 45))
+		widths[2] = max(widths[2], len(r.ResourceStatus))
+	}
+	if width > 0 {
+		widths[4] = max(width-widths[0]-widths[1]-widths[2]-widths[3]-4, 10)
+	}
+
+	printTailLine(w, titles, widths, nil)
+	for _, r := range resources[:rows] {
+		var since string
+		if r.LastUpdatedTimestamp != nil {
+			since = duration.HumanDuration(now().Sub(*r.LastUpdatedTimestamp))
+		}
+		cells := []string{
+			getValue(r.LogicalResourceId),
+			getValue(r.ResourceType),
+			string(r.ResourceStatus),
+			since,
+			getValue(r.ResourceStatusReason),
+		}
+		var colors []string
+		if color {
+			colors = []string{"", "", statusColor(string(r.ResourceStatus))}
+		}
+		printTailLine(w, cells, widths, colors)
+	}
+	if hidden > 0 {
+		fmt.Fprintf(w, "… and %d more\n", hidden)
+	}
+
+	if len(d.recent) > 0 {
+		fmt.Fprintln(w, "\nLatest events:")
+		for _, e := range d.recent {
+			printTailEvent(w, e, false, color, "", width)
+		}
+	}
+}
+
+// progressBar draws done out of total as a bar width characters wide.
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
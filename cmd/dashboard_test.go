@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// newTestDashboard loads prod-app-api's dashboard with its Handler being
+// updated.
+func newTestDashboard(t *testing.T) *dashboard {
+	t.Helper()
+	fake := newFakeClient(t)
+	setNow(t, "2024-06-15T08:35:00Z")
+
+	stack, _ := fake.find(aws.String("prod-app-api"))
+	stack.Resources[1].ResourceStatus = types.ResourceStatusUpdateInProgress
+	stack.Resources[1].ResourceStatusReason = aws.String("Resource creation Initiated")
+
+	ctx := context.Background()
+	events, err := listEventsUntil(ctx, fake, "prod-app-api", isOperationStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortEvents(events, false)
+
+	d := &dashboard{stackName: "prod-app-api"}
+	if !d.record(events) {
+		t.Error("expected the operation start to be recorded")
+	}
+	d.refresh(ctx, fake, "prod-app-api", true)
+	if d.err != nil {
+		t.Fatalf("refresh: %v", d.err)
+	}
+	return d
+}
+
+func TestDashboardRender(t *testing.T) {
+	d := newTestDashboard(t)
+
+	var out strings.Builder
+	d.render(&out, 120, 0, false)
+	lines := strings.Split(out.String(), "\n")
+
+	for i, want := range []string{
+		"prod-app-api  UPDATE_COMPLETE  update started 2024-06-15 08:30:00 (5m ago)",
+		"[" + strings.Repeat("█", 26) + strings.Repeat("░", 14) + "]  2/3 resources complete",
+		"",
+		"RESOURCE",
+		"Handler",
+	} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d: got %q, want %q", i, lines[i], want)
+		}
+	}
+	// The resource being updated comes first, with how long it has been
+	if fields := strings.Fields(lines[4]); fields[2] != "UPDATE_IN_PROGRESS" || fields[3] != "3m" {
+		t.Errorf("unexpected resource line %q", lines[4])
+	}
+	if !strings.Contains(out.String(), "Latest events:") || len(d.recent) != dashboardEvents {
+		t.Errorf("expected the %d latest events:\n%s", dashboardEvents, out.String())
+	}
+	if last := lines[len(lines)-2]; !strings.Contains(last, "UPDATE_COMPLETE") {
+		t.Errorf("expected the newest event last, got %q", last)
+	}
+}
+
+func TestDashboardProgress(t *testing.T) {
+	// A finished update counts the resources it left alone
+	d := newTestDashboard(t)
+	d.resources[1].ResourceStatus = types.ResourceStatusUpdateComplete
+	d.resources[1].LastUpdatedTimestamp = aws.Time(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if done, total := d.progress(); done != 3 || total != 3 {
+		t.Errorf("finished update: got %d/%d, want 3/3", done, total)
+	}
+
+	// An update in progress counts only the resources it has touched so far
+	fake := newFakeClient(t)
+	stack, _ := fake.find(aws.String("prod-app-api"))
+	events := append([]types.StackEvent{}, stack.Events...)
+	sortEvents(events, false)
+	d = &dashboard{stackName: "prod-app-api", total: 3}
+	d.record(events[:3]) // the start and both resources in progress
+	if done, total := d.progress(); done != 0 || total != 2 {
+		t.Errorf("update started: got %d/%d, want 0/2", done, total)
+	}
+	d.record(events[3:4])
+	if done, total := d.progress(); done != 1 || total != 2 {
+		t.Errorf("update half done: got %d/%d, want 1/2", done, total)
+	}
+	var out strings.Builder
+	d.render(&out, 120, 0, false)
+	if !strings.Contains(out.String(), "1/2 resources complete in this update") {
+		t.Errorf("unexpected progress:\n%s", out.String())
+	}
+
+	// A create in progress counts out of the template
+	start := time.Date(2024, 6, 15, 8, 30, 0, 0, time.UTC)
+	d = &dashboard{stackStatus: "CREATE_IN_PROGRESS", operation: "create", start: &start, total: 3}
+	d.resources = []types.StackResourceSummary{
+		{LogicalResourceId: aws.String("Queue"), ResourceStatus: types.ResourceStatusCreateComplete, LastUpdatedTimestamp: aws.Time(start.Add(time.Minute))},
+		{LogicalResourceId: aws.String("Handler"), ResourceStatus: types.ResourceStatusCreateInProgress, LastUpdatedTimestamp: aws.Time(start.Add(time.Minute))},
+	}
+	if done, total := d.progress(); done != 1 || total != 3 {
+		t.Errorf("create: got %d/%d, want 1/3", done, total)
+	}
+}
+
+func TestDashboardCountsProcessedTemplate(t *testing.T) {
+	fake := newFakeClient(t)
+	fake.templates["prod-app-api"] = `Transform: AWS::Serverless-2016-10-31
+Resources:
+  Handler:
+    Type: AWS::Serverless::Function
+`
+	fake.processedTemplates = map[string]string{"prod-app-api": `{"Resources": {
+  "Handler": {"Type": "AWS::Lambda::Function"},
+  "HandlerRole": {"Type": "AWS::IAM::Role"},
+  "HandlerQueuePermission": {"Type": "AWS::Lambda::Permission"},
+  "Queue": {"Type": "AWS::SQS::Queue"}
+}}`}
+
+	d := &dashboard{stackName: "prod-app-api"}
+	d.refresh(context.Background(), fake, "prod-app-api", true)
+	if d.err != nil {
+		t.Fatalf("refresh: %v", d.err)
+	}
+	if d.total != 4 {
+		t.Errorf("got %d resources, want the 4 of the processed template", d.total)
+	}
+}
+
+func TestDashboardFitsHeight(t *testing.T) {
+	d := newTestDashboard(t)
+	for _, id := range []string{"A", "B", "C"} {
+		d.resources = append(d.resources, types.StackResourceSummary{
+			LogicalResourceId: aws.String(id),
+			ResourceStatus:    types.ResourceStatusCreateComplete,
+		})
+	}
+
+	var out strings.Builder
+	d.render(&out, 120, 14, false)
+	if !strings.Contains(out.String(), "… and 4 more\n") {
+		t.Errorf("expected the resources that don't fit to be counted:\n%s", out.String())
+	}
+	if got := strings.Count(out.String(), "\n"); got != 14 {
+		t.Errorf("got %d lines, want 14:\n%s", got, out.String())
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	if got, want := progressBar(1, 4, 8), "[██░░░░░░]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := progressBar(0, 0, 4), "[░░░░]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTailDashboardOptions(t *testing.T) {
+	newFakeClient(t)
+	setOutput(t, "")
+
+	for _, tt := range []struct {
+		names []string
+		opts  tailOptions
+	}{
+		{[]string{"prod-app-api", "prod-app-worker"}, tailOptions{}},
+		{nil, tailOptions{match: []string{"prod-"}}},
+		{[]string{"prod-app-api"}, tailOptions{recursive: true}},
+		// Test output is not a terminal
+		{[]string{"prod-app-api"}, tailOptions{}},
+	} {
+		tt.opts.dashboard = true
+		tt.opts.interval = time.Second
+		var err error
+		captureStdout(t, func() { err = runTail(tt.names, tt.opts) })
+		if err == nil {
+			t.Errorf("expected %v %+v to fail", tt.names, tt.opts)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -15,27 +14,31 @@ func DescribeCmd() *cobra.Command {
 		Use:   "describe <stack-name>",
 		Short: "Show full metadata for a CloudFormation stack",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runDescribe(args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDescribe(args[0])
 		},
 	}
 }
 
-func runDescribe(stackName string) {
+func runDescribe(stackName string) error {
 	ctx := context.Background()
-	client := mustClient(ctx)
-
-	output, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
-		StackName: &stackName,
-	})
+	client, err := getClient(ctx)
 	if err != nil {
-		fatalf("failed to describe stack %q: %v\n", stackName, err)
+		return err
 	}
-	if len(output.Stacks) == 0 {
-		fatalf("stack %q not found\n", stackName)
+
+	stack, err := describeStack(ctx, client, stackName)
+	if err != nil {
+		return err
 	}
 
-	stack := output.Stacks[0]
+	switch {
+	case isObjectOutput():
+		return printObject(stack)
+	case outputFormat == outputName:
+		printNames([]string{getValue(stack.StackName)})
+		return nil
+	}
 
 	// Basic info
 	fmt.Printf("Name:                  %s\n", getValue(stack.StackName))
@@ -76,7 +79,9 @@ func runDescribe(stackName string) {
 				Cells: []interface{}{getValue(p.ParameterKey), val, resolved},
 			})
 		}
-		mustPrint(table)
+		if err := printTable(table); err != nil {
+			return err
+		}
 	}
 
 	// Outputs
@@ -93,7 +98,9 @@ func runDescribe(stackName string) {
 				},
 			})
 		}
-		mustPrint(table)
+		if err := printTable(table); err != nil {
+			return err
+		}
 	}
 
 	// Tags
@@ -105,7 +112,9 @@ func runDescribe(stackName string) {
 				Cells: []interface{}{getValue(t.Key), getValue(t.Value)},
 			})
 		}
-		mustPrint(table)
+		if err := printTable(table); err != nil {
+			return err
+		}
 	}
 
 	// Capabilities
@@ -119,4 +128,5 @@ func runDescribe(stackName string) {
 		}
 		fmt.Println()
 	}
+	return nil
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,6 +13,18 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// driftResult is the structured (--output json|yaml) form of a drift detection.
+type driftResult struct {
+	StackName                 string
+	StackDriftDetectionId     string
+	DetectionStatus           types.StackDriftDetectionStatus `json:",omitempty"`
+	StackDriftStatus          types.StackDriftStatus          `json:",omitempty"`
+	DriftedStackResourceCount int32
+	StackResourceDrifts       []types.StackResourceDrift
+}
+
+func (r driftResult) listItems() []any { return toItems(r.StackResourceDrifts) }
+
 func DriftCmd() *cobra.Command {
 	var wait bool
 
@@ -19,8 +32,8 @@ func DriftCmd() *cobra.Command {
 		Use:   "drift <stack-name>",
 		Short: "Detect and show drift for a CloudFormation stack",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runDrift(args[0], wait)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDrift(args[0], wait)
 		},
 	}
 
@@ -29,58 +42,77 @@ func DriftCmd() *cobra.Command {
 	return cmd
 }
 
-func runDrift(stackName string, wait bool) {
+func runDrift(stackName string, wait bool) error {
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	// Initiate detection
 	initOut, err := client.DetectStackDrift(ctx, &cloudformation.DetectStackDriftInput{
 		StackName: &stackName,
 	})
 	if err != nil {
-		fatalf("failed to initiate drift detection for %q: %v\n", stackName, err)
+		return fmt.Errorf("failed to initiate drift detection for %q: %w", stackName, err)
 	}
 
 	detectionID := getValue(initOut.StackDriftDetectionId)
-	fmt.Printf("Drift detection started (ID: %s)\n", detectionID)
 
 	if !wait {
-		fmt.Println("Use --wait to poll for results automatically.")
-		return
+		switch {
+		case isObjectOutput():
+			return printObject(driftResult{
+				StackName:             stackName,
+				StackDriftDetectionId: detectionID,
+				StackResourceDrifts:   []types.StackResourceDrift{},
+			})
+		case outputFormat == outputName:
+			fmt.Println(detectionID)
+		default:
+			fmt.Printf("Drift detection started (ID: %s)\n", detectionID)
+			fmt.Println("Use --wait to poll for results automatically.")
+		}
+		return nil
 	}
 
+	// Progress goes to stderr so it never mixes with structured output on stdout
+	fmt.Fprintf(os.Stderr, "Drift detection started (ID: %s)\n", detectionID)
+
 	// Poll until complete
-	fmt.Print("Waiting")
+	fmt.Fprint(os.Stderr, "Waiting")
 	for {
 		time.Sleep(3 * time.Second)
-		fmt.Print(".")
+		fmt.Fprint(os.Stderr, ".")
 
 		status, err := client.DescribeStackDriftDetectionStatus(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
 			StackDriftDetectionId: &detectionID,
 		})
 		if err != nil {
-			fatalf("\nfailed to get drift status: %v\n", err)
+			fmt.Fprintln(os.Stderr)
+			return fmt.Errorf("failed to get drift status: %w", err)
 		}
 
 		switch status.DetectionStatus {
 		case types.StackDriftDetectionStatusDetectionComplete:
-			fmt.Println()
-			printDriftResults(ctx, client, stackName, status)
-			return
+			fmt.Fprintln(os.Stderr)
+			if err := printDriftResults(ctx, client, stackName, status); err != nil {
+				return err
+			}
+			if status.StackDriftStatus == types.StackDriftStatusDrifted {
+				return withExitCode(exitDriftDetected, fmt.Errorf("stack %q has drifted (%d resources)",
+					stackName, aws.ToInt32(status.DriftedStackResourceCount)))
+			}
+			return nil
 		case types.StackDriftDetectionStatusDetectionFailed:
-			fmt.Println()
-			fatalf("drift detection failed: %s\n", getValue(status.DetectionStatusReason))
+			fmt.Fprintln(os.Stderr)
+			return fmt.Errorf("drift detection failed: %s", getValue(status.DetectionStatusReason))
 		}
 		// DETECTION_IN_PROGRESS — keep polling
 	}
 }
 
-func printDriftResults(ctx context.Context, client *cloudformation.Client, stackName string, status *cloudformation.DescribeStackDriftDetectionStatusOutput) {
-	fmt.Printf("\nStack drift status: %s\n", string(status.StackDriftStatus))
-	fmt.Printf("Drifted resources:  %d\n\n",
-		aws.ToInt32(status.DriftedStackResourceCount),
-	)
-
+func printDriftResults(ctx context.Context, client cloudFormationAPI, stackName string, status *cloudformation.DescribeStackDriftDetectionStatusOutput) error {
 	// List drifted resources
 	var drifted []types.StackResourceDrift
 	paginator := cloudformation.NewDescribeStackResourceDriftsPaginator(client, &cloudformation.DescribeStackResourceDriftsInput{
@@ -94,29 +126,56 @@ func printDriftResults(ctx context.Context, client *cloudformation.Client, stack
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			fatalf("failed to list drifted resources: %v\n", err)
+			return fmt.Errorf("failed to list drifted resources: %w", err)
 		}
 		drifted = append(drifted, output.StackResourceDrifts...)
 	}
 
+	switch {
+	case isObjectOutput():
+		return printObject(driftResult{
+			StackName:                 stackName,
+			StackDriftDetectionId:     getValue(status.StackDriftDetectionId),
+			DetectionStatus:           status.DetectionStatus,
+			StackDriftStatus:          status.StackDriftStatus,
+			DriftedStackResourceCount: aws.ToInt32(status.DriftedStackResourceCount),
+			StackResourceDrifts:       append([]types.StackResourceDrift{}, drifted...),
+		})
+	case outputFormat == outputName:
+		for _, d := range drifted {
+			fmt.Println(getValue(d.LogicalResourceId))
+		}
+		return nil
+	}
+
+	fmt.Printf("\nStack drift status: %s\n", string(status.StackDriftStatus))
+	fmt.Printf("Drifted resources:  %d\n\n",
+		aws.ToInt32(status.DriftedStackResourceCount),
+	)
+
 	if len(drifted) == 0 {
 		fmt.Println("No drifted resources.")
-		return
+		return nil
 	}
 
-	table := makeTable([]string{"LOGICAL ID", "TYPE", "DRIFT STATUS", "PROPERTY DIFFS"})
+	wide := isWideOutput()
+	columns := []string{"LOGICAL ID", "TYPE", "DRIFT STATUS", "PROPERTY DIFFS"}
+	if wide {
+		columns = []string{"LOGICAL ID", "PHYSICAL ID", "TYPE", "DRIFT STATUS", "PROPERTY DIFFS"}
+	}
+	table := makeTable(columns)
 	for _, d := range drifted {
 		diffs := fmt.Sprintf("%d properties", len(d.PropertyDifferences))
-		table.Rows = append(table.Rows, v1.TableRow{
-			Cells: []interface{}{
-				getValue(d.LogicalResourceId),
-				getValue(d.ResourceType),
-				string(d.StackResourceDriftStatus),
-				diffs,
-			},
-		})
+		cells := []interface{}{getValue(d.LogicalResourceId)}
+		if wide {
+			cells = append(cells, getValue(d.PhysicalResourceId))
+		}
+		cells = append(cells, getValue(d.ResourceType), string(d.StackResourceDriftStatus), diffs)
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
+	}
+	if err := printTable(table); err != nil {
+		return err
 	}
-	mustPrint(table)
 
 	// Show property-level detail
 	for _, d := range drifted {
@@ -130,4 +189,5 @@ func printDriftResults(ctx context.Context, client *cloudformation.Client, stack
 			fmt.Printf("    Actual:   %s\n", getValue(diff.ActualValue))
 		}
 	}
+	return nil
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+func detectDrift(t *testing.T, client *fakeCloudFormation, stackName string) *cloudformation.DescribeStackDriftDetectionStatusOutput {
+	t.Helper()
+	ctx := context.Background()
+	init, err := client.DetectStackDrift(ctx, &cloudformation.DetectStackDriftInput{StackName: aws.String(stackName)})
+	if err != nil {
+		t.Fatalf("DetectStackDrift: %v", err)
+	}
+	status, err := client.DescribeStackDriftDetectionStatus(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
+		StackDriftDetectionId: init.StackDriftDetectionId,
+	})
+	if err != nil {
+		t.Fatalf("DescribeStackDriftDetectionStatus: %v", err)
+	}
+	return status
+}
+
+func captureDriftResults(t *testing.T, client *fakeCloudFormation, stackName string, status *cloudformation.DescribeStackDriftDetectionStatusOutput) string {
+	t.Helper()
+	var err error
+	out := captureStdout(t, func() { err = printDriftResults(context.Background(), client, stackName, status) })
+	if err != nil {
+		t.Fatalf("printDriftResults: %v", err)
+	}
+	return out
+}
+
+func TestPrintDriftResultsTable(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "")
+	status := detectDrift(t, client, "prod-app-api")
+
+	out := captureDriftResults(t, client, "prod-app-api", status)
+
+	for _, want := range []string{
+		"Stack drift status: DRIFTED",
+		"Drifted resources:  1",
+		"AssetsBucket",
+		"MODIFIED",
+		"1 properties",
+		"/VersioningConfiguration/Status",
+		"Expected: Enabled",
+		"Actual:   Suspended",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// IN_SYNC resources are filtered out by the API call
+	if strings.Contains(out, "Handler") {
+		t.Errorf("output should not list in-sync resources:\n%s", out)
+	}
+}
+
+func TestPrintDriftResultsInSync(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "")
+	status := detectDrift(t, client, "prod-network-vpc")
+
+	out := captureDriftResults(t, client, "prod-network-vpc", status)
+
+	if !strings.Contains(out, "Stack drift status: IN_SYNC") || !strings.Contains(out, "No drifted resources.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestPrintDriftResultsJSON(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "json")
+	status := detectDrift(t, client, "prod-app-api")
+
+	out := captureDriftResults(t, client, "prod-app-api", status)
+
+	var result driftResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if result.StackDriftStatus != "DRIFTED" || result.DriftedStackResourceCount != 1 {
+		t.Errorf("unexpected drift summary: %+v", result)
+	}
+	if len(result.StackResourceDrifts) != 1 || getValue(result.StackResourceDrifts[0].LogicalResourceId) != "AssetsBucket" {
+		t.Errorf("unexpected drifted resources: %+v", result.StackResourceDrifts)
+	}
+}
+
+func TestPrintDriftResultsName(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "name")
+	status := detectDrift(t, client, "prod-app-api")
+
+	out := captureDriftResults(t, client, "prod-app-api", status)
+
+	if out != "AssetsBucket\n" {
+		t.Errorf("got %q", out)
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/aws/smithy-go"
+)
+
+// Process exit codes. Scripts can branch on these, so they must stay stable.
+const (
+	exitOK               = 0
+	exitError            = 1 // any other failure, including invalid usage
+	exitNotFound         = 2 // the stack (or other named object) does not exist
+	exitNoMatches        = 3 // a list or search matched nothing
+	exitDriftDetected    = 4 // drift detection found drifted resources
+	exitValidationFailed = 5 // the template failed validation
+	exitAuthError        = 6 // missing, expired or insufficient credentials
+	exitThrottled        = 7 // the request was rate limited by AWS
+	exitOperationFailed  = 8 // the awaited stack operation failed or rolled back
+	exitTimeout          = 9 // gave up waiting for a stack operation
+)
+
+// codedError attaches a specific exit code to an error.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// Error codes returned by AWS that map onto the documented exit codes.
+var (
+	authErrorCodes = map[string]bool{
+		"AccessDenied":                true,
+		"AccessDeniedException":       true,
+		"ExpiredToken":                true,
+		"ExpiredTokenException":       true,
+		"InvalidClientTokenId":        true,
+		"MissingAuthenticationToken":  true,
+		"SignatureDoesNotMatch":       true,
+		"UnrecognizedClientException": true,
+	}
+	throttlingErrorCodes = map[string]bool{
+		"Throttling":                             true,
+		"ThrottlingException":                    true,
+		"ThrottledException":                     true,
+		"RequestLimitExceeded":                   true,
+		"RequestThrottled":                       true,
+		"RequestThrottledException":              true,
+		"TooManyRequestsException":               true,
+		"ProvisionedThroughputExceededException": true,
+	}
+)
+
+// stackNotFoundMessage matches the messages of the ValidationError returned
+// for a missing stack, such as "Stack with id foo does not exist". Template
+// validation errors can also say that something does not exist, so the stack
+// has to be named.
+var stackNotFoundMessage = regexp.MustCompile(`^Stack (with id \S+|\[[^\]]*\]) does not exist`)
+
+// ExitCode returns the process exit code for an error returned by a command.
+// Explicit codes set with withExitCode win; otherwise AWS API errors are
+// classified by their error code.
+func ExitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch code := apiErr.ErrorCode(); {
+		case authErrorCodes[code]:
+			return exitAuthError
+		case throttlingErrorCodes[code]:
+			return exitThrottled
+		case code == "ValidationError" && stackNotFoundMessage.MatchString(apiErr.ErrorMessage()):
+			return exitNotFound
+		}
+	}
+
+	return exitError
+}
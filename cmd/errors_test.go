@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: exitOK},
+		{name: "plain error", err: errors.New("boom"), want: exitError},
+		{name: "explicit code", err: withExitCode(exitDriftDetected, errors.New("drifted")), want: exitDriftDetected},
+		{name: "wrapped explicit code", err: fmt.Errorf("outer: %w", withExitCode(exitNoMatches, errors.New("none"))), want: exitNoMatches},
+		{
+			name: "stack does not exist",
+			err:  fmt.Errorf("failed: %w", &smithy.GenericAPIError{Code: "ValidationError", Message: "Stack with id foo does not exist"}),
+			want: exitNotFound,
+		},
+		{
+			name: "stack named in brackets does not exist",
+			err:  &smithy.GenericAPIError{Code: "ValidationError", Message: "Stack [foo] does not exist"},
+			want: exitNotFound,
+		},
+		{
+			name: "template names something that does not exist",
+			err:  &smithy.GenericAPIError{Code: "ValidationError", Message: "Template format error: Parameter 'Env' does not exist"},
+			want: exitError,
+		},
+		{
+			name: "other validation error",
+			err:  &smithy.GenericAPIError{Code: "ValidationError", Message: "Template format error"},
+			want: exitError,
+		},
+		{name: "access denied", err: &smithy.GenericAPIError{Code: "AccessDenied"}, want: exitAuthError},
+		{name: "expired token", err: &smithy.GenericAPIError{Code: "ExpiredToken"}, want: exitAuthError},
+		{name: "throttling", err: &smithy.GenericAPIError{Code: "Throttling"}, want: exitThrottled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	newFakeClient(t)
+	setOutput(t, "")
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("Description: no resources\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want int
+	}{
+		{name: "describe missing stack", run: func() error { return runDescribe("missing") }, want: exitNotFound},
+		{name: "outputs missing stack", run: func() error { return runOutputs("missing") }, want: exitNotFound},
+		{name: "events missing stack", run: func() error { return runEvents("missing", eventsOptions{}) }, want: exitNotFound},
+		{name: "describe existing stack", run: func() error { return runDescribe("prod-app-api") }, want: exitOK},
+		{name: "validate invalid template", run: func() error { return runValidate(invalid) }, want: exitValidationFailed},
+		{name: "validate unreadable file", run: func() error { return runValidate(filepath.Join(t.TempDir(), "missing.yaml")) }, want: exitError},
+		{
+			name: "list without matches",
+			run: func() error {
+				cmd := ListCmd()
+				t.Cleanup(func() { nameFilters = nil })
+				return runList(cmd, []string{"no-such-stack"})
+			},
+			want: exitNoMatches,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			captureStdout(t, func() { err = tt.run() })
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("exit code %d (err: %v), want %d", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -3,40 +3,293 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
 )
 
+// eventList is the structured (--output json|yaml) form of a stack's events.
+type eventList struct {
+	StackEvents []types.StackEvent
+}
+
+func (l eventList) listItems() []any { return toItems(l.StackEvents) }
+
+// isStackEvent reports whether an event is about the stack itself rather than
+// one of its resources; those use the stack name as logical ID.
+func isStackEvent(e types.StackEvent) bool {
+	if getValue(e.ResourceType) != nestedStackType {
+		return false
+	}
+	physicalID, logicalID := getValue(e.PhysicalResourceId), getValue(e.LogicalResourceId)
+	return (physicalID != "" && physicalID == getValue(e.StackId)) || (logicalID != "" && logicalID == getValue(e.StackName))
+}
+
+// isOperationStart reports whether an event starts a stack operation: the
+// stack's own event with the "User Initiated" reason.
+func isOperationStart(e types.StackEvent) bool {
+	return isStackEvent(e) && getValue(e.ResourceStatusReason) == "User Initiated"
+}
+
+// operationKind names the operation an event starts, e.g. "update" for
+// UPDATE_IN_PROGRESS.
+func operationKind(e types.StackEvent) string {
+	kind, _, _ := strings.Cut(string(e.ResourceStatus), "_")
+	return strings.ToLower(kind)
+}
+
+// eventsOptions are the flags of `cfn events`.
+type eventsOptions struct {
+	limit         int
+	recursive     bool
+	operation     string
+	since         string
+	until         string
+	statuses      []string
+	logicalIDs    []string
+	resourceTypes []string
+}
+
 func EventsCmd() *cobra.Command {
-	var limit int
+	var opts eventsOptions
 
 	cmd := &cobra.Command{
 		Use:   "events <stack-name>",
 		Short: "List events for a CloudFormation stack",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runEvents(args[0], limit)
+		Long: `List events for a CloudFormation stack, newest first. On a terminal the
+statuses are coloured by outcome (see --color).
+
+With --operation the events are grouped by stack operation (create, update,
+delete, import), each starting at its "User Initiated" event and summarised
+with its duration, final stack status and how many resources were created,
+updated, deleted or failed. "--operation last" shows just the last
+deployment, "--operation 3" the last three and "--operation all" every one.
+Only the events of the selected operations are fetched.
+
+--since and --until take an age ("2h", "3d") or a time ("2024-06-15",
+"2024-06-15T08:30:00Z"). --status, --logical-id and --type can be repeated
+and match case-insensitively, as substrings or as globs (--status FAILED,
+--type 'AWS::Lambda::*').
+
+With --recursive the events of every nested stack are included as well,
+interleaved by time with a STACK column, so the failure behind an "Embedded
+stack ... was not successfully created" event is shown next to it. Nested
+stacks are found through the events of their parents, including nested
+stacks that have since been deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEvents(args[0], opts)
 		},
 	}
 
-	cmd.Flags().IntVarP(&limit, "limit", "l", 0, "Maximum number of events to show (0 = all)")
+	cmd.Flags().IntVarP(&opts.limit, "limit", "l", 0, "Maximum number of events to show (0 = all)")
+	cmd.Flags().BoolVar(&opts.recursive, "recursive", false, "Include the events of nested stacks")
+	cmd.Flags().StringVar(&opts.operation, "operation", "", "Group events by operation and show the last, a number of or all operations (last|N|all)")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only show events after this time or age (e.g. 2h, 2024-06-15)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only show events before this time or age")
+	cmd.Flags().StringArrayVar(&opts.statuses, "status", nil, "Only show events with a matching status (repeatable, e.g. FAILED)")
+	cmd.Flags().StringArrayVar(&opts.logicalIDs, "logical-id", nil, "Only show events of matching logical IDs (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.resourceTypes, "type", nil, "Only show events of matching resource types (repeatable)")
 
 	return cmd
 }
 
-func runEvents(stackName string, limit int) {
+// eventFilter selects events by time and by status, logical ID and type.
+type eventFilter struct {
+	since, until  time.Time
+	statuses      *nameMatcher
+	logicalIDs    *nameMatcher
+	resourceTypes *nameMatcher
+}
+
+func newEventFilter(opts eventsOptions) (*eventFilter, error) {
+	f := &eventFilter{}
+	var err error
+	if opts.since != "" {
+		if f.since, err = parseTimeBound(opts.since); err != nil {
+			return nil, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if opts.until != "" {
+		if f.until, err = parseTimeBound(opts.until); err != nil {
+			return nil, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if f.statuses, err = newNameMatcher(opts.statuses, nil, false, true); err != nil {
+		return nil, err
+	}
+	if f.logicalIDs, err = newNameMatcher(opts.logicalIDs, nil, false, true); err != nil {
+		return nil, err
+	}
+	if f.resourceTypes, err = newNameMatcher(opts.resourceTypes, nil, false, true); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// selective reports whether the filter can drop events other than by time.
+func (f *eventFilter) selective() bool {
+	return len(f.statuses.include) > 0 || len(f.logicalIDs.include) > 0 || len(f.resourceTypes.include) > 0
+}
+
+func (f *eventFilter) match(e types.StackEvent) bool {
+	t := eventTime(e)
+	if (!f.since.IsZero() && t.Before(f.since)) || (!f.until.IsZero() && t.After(f.until)) {
+		return false
+	}
+	return f.statuses.match(string(e.ResourceStatus)) &&
+		f.logicalIDs.match(getValue(e.LogicalResourceId)) &&
+		f.resourceTypes.match(getValue(e.ResourceType))
+}
+
+func (f *eventFilter) apply(events []types.StackEvent) []types.StackEvent {
+	var kept []types.StackEvent
+	for _, e := range events {
+		if f.match(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// eventsStop returns the predicate that ends the listing of a stack's events,
+// or nil to list them all: after the requested number of operations, or once
+// past --since. Grouped listings read back to the start of the operation
+// running at --since.
+func eventsStop(stackName string, operations int, since time.Time) func(types.StackEvent) bool {
+	if operations == 0 {
+		if since.IsZero() {
+			return nil
+		}
+		return func(e types.StackEvent) bool { return eventTime(e).Before(since) }
+	}
+	if operations < 0 && since.IsZero() {
+		return nil
+	}
+	seen := 0
+	return func(e types.StackEvent) bool {
+		if !isOperationStart(e) || !belongsTo(e, stackName) {
+			return false
+		}
+		seen++
+		return seen == operations || (!since.IsZero() && eventTime(e).Before(since))
+	}
+}
+
+func runEvents(stackName string, opts eventsOptions) error {
+	operations, err := parseOperationCount(opts.operation)
+	if err != nil {
+		return err
+	}
+	if operations != 0 && opts.limit > 0 {
+		return fmt.Errorf("--limit cannot be combined with --operation")
+	}
+	filter, err := newEventFilter(opts)
+	if err != nil {
+		return err
+	}
+
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Filters are applied after listing, so --limit can only cut the listing
+	// short without them
+	fetchLimit := opts.limit
+	if filter.selective() || !filter.until.IsZero() {
+		fetchLimit = 0
+	}
+	stop := eventsStop(stackName, operations, filter.since)
 
-	events, err := listEvents(ctx, client, stackName, limit)
+	var events []types.StackEvent
+	switch {
+	case opts.recursive:
+		events, err = listEventsRecursive(ctx, client, stackName, fetchLimit, stop)
+	case stop != nil:
+		events, err = listEventsUntil(ctx, client, stackName, stop)
+	default:
+		events, err = listEvents(ctx, client, stackName, fetchLimit)
+	}
 	if err != nil {
-		fatalf("failed to list events for stack %q: %v\n", stackName, err)
+		return fmt.Errorf("failed to list events for stack %q: %w", stackName, err)
+	}
+
+	if operations != 0 {
+		return printOperations(selectOperations(events, stackName, operations, filter), opts.recursive)
+	}
+
+	events = filter.apply(events)
+	if opts.limit > 0 && len(events) > opts.limit {
+		events = events[:opts.limit]
+	}
+
+	switch {
+	case isObjectOutput():
+		return printObject(eventList{StackEvents: append([]types.StackEvent{}, events...)})
+	case outputFormat == outputName:
+		for _, e := range events {
+			fmt.Println(getValue(e.EventId))
+		}
+		return nil
 	}
 
 	if len(events) == 0 {
 		fmt.Println("No events found")
-		return
+		return nil
+	}
+
+	return printEvents(noHeaders, events, opts.recursive)
+}
+
+// selectOperations groups events into operations, keeps the newest count
+// (all when negative) and filters their events, dropping operations left
+// without any. Summaries are worked out before filtering.
+func selectOperations(events []types.StackEvent, stackName string, count int, filter *eventFilter) []stackOperation {
+	ops := groupOperations(events, stackName)
+	if count > 0 && len(ops) > count {
+		ops = ops[:count]
+	}
+	var kept []stackOperation
+	for _, op := range ops {
+		if op.StackEvents = filter.apply(op.StackEvents); len(op.StackEvents) > 0 {
+			kept = append(kept, op)
+		}
+	}
+	return kept
+}
+
+func printOperations(ops []stackOperation, showStack bool) error {
+	switch {
+	case isObjectOutput():
+		return printObject(operationList{Operations: append([]stackOperation{}, ops...)})
+	case outputFormat == outputName:
+		for _, op := range ops {
+			for _, e := range op.StackEvents {
+				fmt.Println(getValue(e.EventId))
+			}
+		}
+		return nil
+	}
+
+	if len(ops) == 0 {
+		fmt.Println("No events found")
+		return nil
 	}
 
-	printEvents(noHeaders, events)
+	for i, op := range ops {
+		if !noHeaders {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(operationSummary(op))
+		}
+		if err := printEvents(noHeaders, op.StackEvents, showStack); err != nil {
+			return err
+		}
+	}
+	return nil
 }
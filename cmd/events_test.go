@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func runEventsOutput(t *testing.T, format string, opts eventsOptions) string {
+	t.Helper()
+	newNestedFakeClient(t)
+	setOutput(t, format)
+
+	var err error
+	out := captureStdout(t, func() { err = runEvents("shop", opts) })
+	if err != nil {
+		t.Fatalf("runEvents: %v", err)
+	}
+	return out
+}
+
+func TestEventsLastOperation(t *testing.T) {
+	out := runEventsOutput(t, "", eventsOptions{operation: "last", recursive: true})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if want := "update started 2024-09-10 14:00:00, took 4m30s, UPDATE_ROLLBACK_COMPLETE (1 updated, 1 failed)"; lines[0] != want {
+		t.Errorf("got summary %q, want %q", lines[0], want)
+	}
+	if strings.Contains(out, "CREATE_") {
+		t.Errorf("unexpected events of the create operation:\n%s", out)
+	}
+	if !strings.Contains(out, "Invalid node type") {
+		t.Errorf("expected the nested stacks' events:\n%s", out)
+	}
+}
+
+func TestEventsAllOperations(t *testing.T) {
+	var result operationList
+	if err := json.Unmarshal([]byte(runEventsOutput(t, "json", eventsOptions{operation: "all"})), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(result.Operations) != 2 {
+		t.Fatalf("expected two operations, got %d", len(result.Operations))
+	}
+	update, create := result.Operations[0], result.Operations[1]
+	if update.Operation != "update" || update.StackStatus != "UPDATE_ROLLBACK_COMPLETE" || len(update.StackEvents) != 11 {
+		t.Errorf("unexpected update operation %s %s with %d events", update.Operation, update.StackStatus, len(update.StackEvents))
+	}
+	// Without --recursive the nested stacks count as resources
+	if update.Resources["failed"] != 1 || update.Resources["updated"] != 1 {
+		t.Errorf("unexpected update resources %v", update.Resources)
+	}
+	if create.Operation != "create" || create.Resources["created"] != 1 || create.EndTime.Sub(*create.StartTime) != time.Minute {
+		t.Errorf("unexpected create operation %+v", create)
+	}
+}
+
+func TestEventsFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts eventsOptions
+		want []string
+	}{
+		{
+			name: "status substring",
+			opts: eventsOptions{recursive: true, statuses: []string{"failed"}},
+			want: []string{"shop-5", "db-5", "db-4", "cache-3"},
+		},
+		{
+			name: "type glob and logical ID",
+			opts: eventsOptions{recursive: true, resourceTypes: []string{"AWS::RDS::*"}, logicalIDs: []string{"Db"}},
+			want: []string{"db-8", "db-5", "db-3"},
+		},
+		{
+			name: "time window",
+			opts: eventsOptions{since: "2024-09-10T14:03:00Z", until: "2024-09-10T14:04:00Z"},
+			want: []string{"shop-10", "shop-9", "shop-8"},
+		},
+		{
+			name: "limit after filters",
+			opts: eventsOptions{recursive: true, statuses: []string{"*_FAILED"}, limit: 2},
+			want: []string{"shop-5", "db-5"},
+		},
+		{
+			name: "operation and status",
+			opts: eventsOptions{operation: "all", statuses: []string{"CREATE_COMPLETE"}},
+			want: []string{"shop-create-4", "shop-create-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runEventsOutput(t, "name", tt.opts)
+			if got := strings.Fields(out); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsLastOperationStopsListing(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	setOutput(t, "name")
+
+	var err error
+	captureStdout(t, func() { err = runEvents("shop", eventsOptions{operation: "last"}) })
+	if err != nil {
+		t.Fatalf("runEvents: %v", err)
+	}
+	// 15 events at 2 per page; the operation starts on page 6
+	if got := fake.calls["DescribeStackEvents"]; got != 6 {
+		t.Errorf("got %d DescribeStackEvents calls, want 6", got)
+	}
+}
+
+func TestEventsInvalidOptions(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	for _, opts := range []eventsOptions{
+		{operation: "first"},
+		{operation: "0"},
+		{operation: "last", limit: 5},
+		{since: "yesterday"},
+	} {
+		if err := runEvents("shop", opts); err == nil {
+			t.Errorf("expected %+v to fail", opts)
+		}
+	}
+}
+
+func TestEventsColor(t *testing.T) {
+	plain := runEventsOutput(t, "", eventsOptions{operation: "all"})
+	setColor(t, colorAlways)
+	var err error
+	colored := captureStdout(t, func() { err = runEvents("shop", eventsOptions{operation: "all"}) })
+	if err != nil {
+		t.Fatalf("runEvents: %v", err)
+	}
+
+	for _, want := range []string{
+		ansiGreen + "CREATE_COMPLETE" + ansiReset + " ",
+		ansiMagenta + "UPDATE_ROLLBACK_COMPLETE" + ansiReset,
+		ansiRed + "UPDATE_FAILED" + ansiReset,
+	} {
+		if !strings.Contains(colored, want) {
+			t.Errorf("expected %q in:\n%s", want, colored)
+		}
+	}
+	// Colours don't change the layout
+	if got := ansiEscape.ReplaceAllString(colored, ""); got != plain {
+		t.Errorf("got\n%s\nwant\n%s", got, plain)
+	}
+}
+
+func TestResourceOutcome(t *testing.T) {
+	for status, want := range map[string]string{
+		"CREATE_COMPLETE":    "created",
+		"UPDATE_COMPLETE":    "updated",
+		"DELETE_COMPLETE":    "deleted",
+		"IMPORT_COMPLETE":    "imported",
+		"DELETE_SKIPPED":     "retained",
+		"UPDATE_IN_PROGRESS": "in progress",
+		"CREATE_FAILED":      "failed",
+	} {
+		if got := resourceOutcome(types.ResourceStatus(status)); got != want {
+			t.Errorf("resourceOutcome(%s) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeStack is one stack of the in-memory fake, as stored in testdata/stacks.json.
+type fakeStack struct {
+	Stack     types.Stack
+	Template  string // file name under testdata/templates
+	Events    []types.StackEvent
+	Resources []types.StackResourceSummary
+	Drifts    []types.StackResourceDrift
+}
+
+// fakeCloudFormation is an in-memory cloudFormationAPI seeded from fixtures.
+type fakeCloudFormation struct {
+	stacks    []*fakeStack
+	templates map[string]string
+
+	// pageSize splits list responses into pages so pagination is exercised.
+	pageSize int
+	// processedTemplates, when set for a stack, is its template with any
+	// Transform applied; otherwise both stages are the same.
+	processedTemplates map[string]string
+	// templateErrors makes GetTemplate fail for the given stack names.
+	templateErrors map[string]error
+	// describeErrors makes DescribeStacks fail for the given stack names.
+	describeErrors map[string]error
+	// eventErrors makes DescribeStackEvents fail for the given stack names.
+	eventErrors map[string]error
+	// calls counts API invocations by operation name.
+	calls map[string]int
+	// pollEvents, when set, runs before the first page of every
+	// DescribeStackEvents call, so tests can make a stack progress.
+	pollEvents func(*fakeStack)
+
+	mu sync.Mutex
+}
+
+var _ cloudFormationAPI = (*fakeCloudFormation)(nil)
+
+// newFakeClient loads testdata/stacks.json and installs the fake as the
+// client returned by getClient for the duration of the test.
+func newFakeClient(t *testing.T) *fakeCloudFormation {
+	t.Helper()
+
+	fake := &fakeCloudFormation{
+		templates:      make(map[string]string),
+		pageSize:       2,
+		templateErrors: make(map[string]error),
+		calls:          make(map[string]int),
+	}
+	fake.load(t, "stacks.json")
+
+	// Keep the template cache away from the developer's own
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	previous := newClient
+	newClient = func(context.Context, target) (cloudFormationAPI, error) { return fake, nil }
+	t.Cleanup(func() { newClient = previous })
+
+	return fake
+}
+
+// load appends the stacks of a fixture file under testdata, with their
+// templates.
+func (f *fakeCloudFormation) load(t *testing.T, fixture string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+	var stacks []*fakeStack
+	if err := json.Unmarshal(data, &stacks); err != nil {
+		t.Fatalf("failed to decode fixtures: %v", err)
+	}
+
+	for _, s := range stacks {
+		f.stacks = append(f.stacks, s)
+		if s.Template == "" {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", "templates", s.Template))
+		if err != nil {
+			t.Fatalf("failed to read template fixture: %v", err)
+		}
+		f.templates[getValue(s.Stack.StackName)] = string(body)
+	}
+}
+
+// newNestedFakeClient is newFakeClient plus the nested "shop" application
+// from testdata/nested.json: shop > shop-Database > shop-Database-Cache, and
+// shop > shop-Network.
+func newNestedFakeClient(t *testing.T) *fakeCloudFormation {
+	t.Helper()
+	fake := newFakeClient(t)
+	fake.load(t, "nested.json")
+	return fake
+}
+
+// record counts a call; commands may call the fake from several goroutines.
+func (f *fakeCloudFormation) record(op string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[op]++
+}
+
+func (f *fakeCloudFormation) find(nameOrID *string) (*fakeStack, error) {
+	for _, s := range f.stacks {
+		if getValue(s.Stack.StackName) == getValue(nameOrID) || getValue(s.Stack.StackId) == getValue(nameOrID) {
+			return s, nil
+		}
+	}
+	return nil, &smithy.GenericAPIError{
+		Code:    "ValidationError",
+		Message: fmt.Sprintf("Stack with id %s does not exist", getValue(nameOrID)),
+	}
+}
+
+// page returns the slice of n items selected by token and the token of the next page.
+func (f *fakeCloudFormation) page(token *string, n int) (int, int, *string) {
+	start := 0
+	if token != nil {
+		start, _ = strconv.Atoi(*token)
+	}
+	end := n
+	if f.pageSize > 0 && start+f.pageSize < n {
+		end = start + f.pageSize
+	}
+	if end < n {
+		return start, end, aws.String(strconv.Itoa(end))
+	}
+	return start, end, nil
+}
+
+func (f *fakeCloudFormation) ListStacks(_ context.Context, in *cloudformation.ListStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.ListStacksOutput, error) {
+	f.record("ListStacks")
+
+	var summaries []types.StackSummary
+	for _, s := range f.stacks {
+		if len(in.StackStatusFilter) > 0 && !containsStatus(in.StackStatusFilter, s.Stack.StackStatus) {
+			continue
+		}
+		summary := types.StackSummary{
+			StackId:             s.Stack.StackId,
+			StackName:           s.Stack.StackName,
+			StackStatus:         s.Stack.StackStatus,
+			StackStatusReason:   s.Stack.StackStatusReason,
+			CreationTime:        s.Stack.CreationTime,
+			LastUpdatedTime:     s.Stack.LastUpdatedTime,
+			DeletionTime:        s.Stack.DeletionTime,
+			TemplateDescription: s.Stack.Description,
+			ParentId:            s.Stack.ParentId,
+			RootId:              s.Stack.RootId,
+		}
+		if s.Stack.DriftInformation != nil {
+			summary.DriftInformation = &types.StackDriftInformationSummary{
+				StackDriftStatus: s.Stack.DriftInformation.StackDriftStatus,
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	start, end, next := f.page(in.NextToken, len(summaries))
+	return &cloudformation.ListStacksOutput{StackSummaries: summaries[start:end], NextToken: next}, nil
+}
+
+func containsStatus(statuses []types.StackStatus, status types.StackStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeCloudFormation) DescribeStacks(_ context.Context, in *cloudformation.DescribeStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	f.record("DescribeStacks")
+
+	if in.StackName != nil {
+		s, err := f.find(in.StackName)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.describeErrors[getValue(s.Stack.StackName)]; err != nil {
+			return nil, err
+		}
+		return &cloudformation.DescribeStacksOutput{Stacks: []types.Stack{s.Stack}}, nil
+	}
+
+	var stacks []types.Stack
+	for _, s := range f.stacks {
+		if s.Stack.StackStatus != types.StackStatusDeleteComplete {
+			stacks = append(stacks, s.Stack)
+		}
+	}
+	start, end, next := f.page(in.NextToken, len(stacks))
+	return &cloudformation.DescribeStacksOutput{Stacks: stacks[start:end], NextToken: next}, nil
+}
+
+func (f *fakeCloudFormation) DescribeStackEvents(_ context.Context, in *cloudformation.DescribeStackEventsInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error) {
+	f.record("DescribeStackEvents")
+
+	s, err := f.find(in.StackName)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.eventErrors[getValue(s.Stack.StackName)]; err != nil {
+		return nil, err
+	}
+	if f.pollEvents != nil && in.NextToken == nil {
+		f.pollEvents(s)
+	}
+	start, end, next := f.page(in.NextToken, len(s.Events))
+	return &cloudformation.DescribeStackEventsOutput{StackEvents: s.Events[start:end], NextToken: next}, nil
+}
+
+func (f *fakeCloudFormation) ListStackResources(_ context.Context, in *cloudformation.ListStackResourcesInput, _ ...func(*cloudformation.Options)) (*cloudformation.ListStackResourcesOutput, error) {
+	f.record("ListStackResources")
+
+	s, err := f.find(in.StackName)
+	if err != nil {
+		return nil, err
+	}
+	start, end, next := f.page(in.NextToken, len(s.Resources))
+	return &cloudformation.ListStackResourcesOutput{StackResourceSummaries: s.Resources[start:end], NextToken: next}, nil
+}
+
+func (f *fakeCloudFormation) GetTemplate(_ context.Context, in *cloudformation.GetTemplateInput, _ ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error) {
+	f.record("GetTemplate")
+
+	s, err := f.find(in.StackName)
+	if err != nil {
+		return nil, err
+	}
+	name := getValue(s.Stack.StackName)
+	if err := f.templateErrors[name]; err != nil {
+		return nil, err
+	}
+	if body, ok := f.processedTemplates[name]; ok && in.TemplateStage == types.TemplateStageProcessed {
+		return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(body)}, nil
+	}
+	return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(f.templates[name])}, nil
+}
+
+func (f *fakeCloudFormation) DetectStackDrift(_ context.Context, in *cloudformation.DetectStackDriftInput, _ ...func(*cloudformation.Options)) (*cloudformation.DetectStackDriftOutput, error) {
+	f.record("DetectStackDrift")
+
+	s, err := f.find(in.StackName)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudformation.DetectStackDriftOutput{StackDriftDetectionId: aws.String("detection-" + getValue(s.Stack.StackName))}, nil
+}
+
+func (f *fakeCloudFormation) DescribeStackDriftDetectionStatus(_ context.Context, in *cloudformation.DescribeStackDriftDetectionStatusInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	f.record("DescribeStackDriftDetectionStatus")
+
+	for _, s := range f.stacks {
+		if "detection-"+getValue(s.Stack.StackName) == getValue(in.StackDriftDetectionId) {
+			return driftStatusFor(s), nil
+		}
+	}
+	return nil, &smithy.GenericAPIError{Code: "ValidationError", Message: "Unknown drift detection id"}
+}
+
+// driftStatusFor summarises the fixture drifts the way the API would.
+func driftStatusFor(s *fakeStack) *cloudformation.DescribeStackDriftDetectionStatusOutput {
+	var drifted int32
+	for _, d := range s.Drifts {
+		if d.StackResourceDriftStatus != types.StackResourceDriftStatusInSync {
+			drifted++
+		}
+	}
+	status := types.StackDriftStatusInSync
+	if drifted > 0 {
+		status = types.StackDriftStatusDrifted
+	}
+	return &cloudformation.DescribeStackDriftDetectionStatusOutput{
+		StackId:                   s.Stack.StackId,
+		StackDriftDetectionId:     aws.String("detection-" + getValue(s.Stack.StackName)),
+		DetectionStatus:           types.StackDriftDetectionStatusDetectionComplete,
+		StackDriftStatus:          status,
+		DriftedStackResourceCount: aws.Int32(drifted),
+	}
+}
+
+func (f *fakeCloudFormation) DescribeStackResourceDrifts(_ context.Context, in *cloudformation.DescribeStackResourceDriftsInput, _ ...func(*cloudformation.Options)) (*cloudformation.DescribeStackResourceDriftsOutput, error) {
+	f.record("DescribeStackResourceDrifts")
+
+	s, err := f.find(in.StackName)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []types.StackResourceDrift
+	for _, d := range s.Drifts {
+		if len(in.StackResourceDriftStatusFilters) > 0 && !containsDriftStatus(in.StackResourceDriftStatusFilters, d.StackResourceDriftStatus) {
+			continue
+		}
+		drifts = append(drifts, d)
+	}
+	start, end, next := f.page(in.NextToken, len(drifts))
+	return &cloudformation.DescribeStackResourceDriftsOutput{StackResourceDrifts: drifts[start:end], NextToken: next}, nil
+}
+
+func containsDriftStatus(statuses []types.StackResourceDriftStatus, status types.StackResourceDriftStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeCloudFormation) ValidateTemplate(_ context.Context, in *cloudformation.ValidateTemplateInput, _ ...func(*cloudformation.Options)) (*cloudformation.ValidateTemplateOutput, error) {
+	f.record("ValidateTemplate")
+
+	template, err := parseTemplate(getValue(in.TemplateBody))
+	if err != nil {
+		return nil, &smithy.GenericAPIError{Code: "ValidationError", Message: err.Error()}
+	}
+	if _, ok := template["Resources"]; !ok {
+		return nil, &smithy.GenericAPIError{Code: "ValidationError", Message: "Template format error: At least one Resources member must be defined."}
+	}
+	out := &cloudformation.ValidateTemplateOutput{}
+	if desc, ok := template["Description"].(string); ok {
+		out.Description = aws.String(desc)
+	}
+	return out, nil
+}
+
+// setOutput applies the global flags for a single test and restores the defaults afterwards.
+func setOutput(t *testing.T, format string) {
+	t.Helper()
+	if err := SetGlobalFlags("", false, format, "", AWSOptions{}, CacheOptions{}); err != nil {
+		t.Fatalf("SetGlobalFlags(%q): %v", format, err)
+	}
+	t.Cleanup(func() { _ = SetGlobalFlags("", false, "", "", AWSOptions{}, CacheOptions{}) })
+}
+
+// captureStdout returns everything fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// captureStderr returns everything fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stderr, fn)
+}
+
+func capture(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+
+	original := *f
+	*f = w
+	defer func() { *f = original }()
+
+	fn()
+
+	_ = w.Close()
+	return string(<-done)
+}
@@ -1,48 +1,56 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/cli-runtime/pkg/printers"
 )
 
 var (
-	region    string
-	noHeaders bool
+	region       string
+	noHeaders    bool
+	outputFormat string
+	awsOptions   AWSOptions
+	cacheOptions CacheOptions
 )
 
 // SetGlobalFlags sets the global flags that are used across commands
-func SetGlobalFlags(r string, nh bool) {
-	region = r
-	noHeaders = nh
-}
-
-func mustClient(ctx context.Context) *cloudformation.Client {
-	cfg, err := config.LoadDefaultConfig(ctx, func(opts *config.LoadOptions) error {
-		if region != "" {
-			opts.Region = region
-		}
-		return nil
-	})
+func SetGlobalFlags(r string, nh bool, output, color string, opts AWSOptions, cache CacheOptions) error {
+	printer, err := newObjectPrinter(output)
 	if err != nil {
-		fatalf("failed to load AWS config: %v\n", err)
+		return err
 	}
-	return cloudformation.NewFromConfig(cfg)
-}
-
-func fatalf(format string, args ...any) {
-	fmt.Fprintf(os.Stderr, format, args...)
-	os.Exit(1)
+	mode, err := parseColorMode(color)
+	if err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	region = r
+	awsOptions = opts
+	cacheOptions = cache
+	noHeaders = nh
+	outputFormat = output
+	objectPrinter = printer
+	colorMode = mode
+	return nil
 }
 
-func listStacks(ctx context.Context, client *cloudformation.Client, statusFilters []types.StackStatus, nameFilter, descContains, descNotContains string, ignoreCase bool) ([]types.StackSummary, error) {
+func listStacks(ctx context.Context, client cloudFormationAPI, statusFilters []types.StackStatus, names *nameMatcher, descContains, descNotContains string, ignoreCase bool) ([]types.StackSummary, error) {
 	var all []types.StackSummary
 
 	input := &cloudformation.ListStacksInput{}
@@ -57,7 +65,7 @@ func listStacks(ctx context.Context, client *cloudformation.Client, statusFilter
 			return nil, err
 		}
 		for _, stack := range output.StackSummaries {
-			if nameFilter != "" && (stack.StackName == nil || !containsWithCase(*stack.StackName, nameFilter, ignoreCase)) {
+			if !names.match(getValue(stack.StackName)) {
 				continue
 			}
 			desc := getValue(stack.TemplateDescription)
@@ -87,7 +95,38 @@ func equalsWithCase(left, right string, ignoreCase bool) bool {
 	return left == right
 }
 
-func listEvents(ctx context.Context, client *cloudformation.Client, stackName string, limit int) ([]types.StackEvent, error) {
+// describeStack returns a single stack by name or ID.
+func describeStack(ctx context.Context, client cloudFormationAPI, stackName string) (*types.Stack, error) {
+	output, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &stackName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack %q: %w", stackName, err)
+	}
+	if len(output.Stacks) == 0 {
+		return nil, withExitCode(exitNotFound, fmt.Errorf("stack %q not found", stackName))
+	}
+	return &output.Stacks[0], nil
+}
+
+func listStackResources(ctx context.Context, client cloudFormationAPI, stackName string) ([]types.StackResourceSummary, error) {
+	var all []types.StackResourceSummary
+
+	paginator := cloudformation.NewListStackResourcesPaginator(client, &cloudformation.ListStackResourcesInput{
+		StackName: &stackName,
+	})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, output.StackResourceSummaries...)
+	}
+	return all, nil
+}
+
+func listEvents(ctx context.Context, client cloudFormationAPI, stackName string, limit int) ([]types.StackEvent, error) {
 	var all []types.StackEvent
 
 	paginator := cloudformation.NewDescribeStackEventsPaginator(client, &cloudformation.DescribeStackEventsInput{
@@ -107,6 +146,40 @@ func listEvents(ctx context.Context, client *cloudformation.Client, stackName st
 	return all, nil
 }
 
+// listEventsUntil lists a stack's events newest first, up to and including
+// the first one stop returns true for, without fetching older pages.
+func listEventsUntil(ctx context.Context, client cloudFormationAPI, stackName string, stop func(types.StackEvent) bool) ([]types.StackEvent, error) {
+	var all []types.StackEvent
+
+	paginator := cloudformation.NewDescribeStackEventsPaginator(client, &cloudformation.DescribeStackEventsInput{
+		StackName: &stackName,
+	})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range output.StackEvents {
+			all = append(all, e)
+			if stop(e) {
+				return all, nil
+			}
+		}
+	}
+	return all, nil
+}
+
+// listEventsSince lists a stack's events newest first, back to since.
+func listEventsSince(ctx context.Context, client cloudFormationAPI, stackName string, since time.Time) ([]types.StackEvent, error) {
+	older := func(e types.StackEvent) bool { return eventTime(e).Before(since) }
+	events, err := listEventsUntil(ctx, client, stackName, older)
+	if n := len(events); n > 0 && older(events[n-1]) {
+		events = events[:n-1]
+	}
+	return events, err
+}
+
 func buildStatusFilters(all, complete, deleted, inProgress bool) []types.StackStatus {
 	// --all returns nil which means the AWS API default (everything except DELETE_COMPLETE)
 	if all {
@@ -172,6 +245,26 @@ func buildStatusFilters(all, complete, deleted, inProgress bool) []types.StackSt
 	return filters
 }
 
+// Template errors, told apart when reporting stacks a search had to skip.
+var (
+	errEmptyTemplate = errors.New("empty template")
+	errTemplateParse = errors.New("failed to parse template")
+)
+
+// parseTemplate decodes a template body, trying JSON first and then YAML.
+// YAML short-form intrinsics are converted to their long form, so callers
+// see the same shape for either format.
+func parseTemplate(body string) (map[string]interface{}, error) {
+	var template map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &template); err != nil {
+		template, err = decodeTemplateYAML([]byte(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errTemplateParse, err)
+		}
+	}
+	return template, nil
+}
+
 func makeTable(columns []string) *v1.Table {
 	table := &v1.Table{}
 	for _, c := range columns {
@@ -182,56 +275,145 @@ func makeTable(columns []string) *v1.Table {
 	return table
 }
 
-func mustPrint(table *v1.Table) {
+func printTable(table *v1.Table) error {
 	printer := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHeaders})
 	if err := printer.PrintObj(table, os.Stdout); err != nil {
-		fatalf("error printing table: %v\n", err)
+		return fmt.Errorf("error printing table: %w", err)
 	}
+	return nil
+}
+
+// stackTableOptions selects the optional columns of printStacks.
+type stackTableOptions struct {
+	location bool                         // ACCOUNT and REGION, for listings spanning several targets
+	showTags []string                     // extra columns with these tag values
+	tags     map[string]map[string]string // stack ID -> tags, for showTags
+	tree     map[string]string            // stack ID -> tree drawing before NAME, for --tree
 }
 
-func printStacks(noHdrs bool, stacks []types.StackSummary) {
-	table := makeTable([]string{"NAME", "STATUS", "CREATED", "DESCRIPTION"})
+func printStacks(noHdrs bool, stacks []types.StackSummary, opts stackTableOptions) error {
+	wide := isWideOutput()
+	// PARENT is only shown for listings with nested stacks, unless the tree
+	// already shows the nesting
+	parent := false
+	if opts.tree == nil {
+		for _, stack := range stacks {
+			parent = parent || isNestedStack(stack)
+		}
+	}
+	columns := []string{"NAME", "STATUS", "CREATED", "LAST UPDATED", "AGE"}
+	if parent {
+		columns = []string{"NAME", "PARENT", "STATUS", "CREATED", "LAST UPDATED", "AGE"}
+	}
+	if wide {
+		columns = append(columns, "DRIFT")
+	}
+	if opts.location {
+		columns = append([]string{"ACCOUNT", "REGION"}, columns...)
+	}
+	for _, key := range opts.showTags {
+		columns = append(columns, strings.ToUpper(key))
+	}
+	columns = append(columns, "DESCRIPTION")
+	table := makeTable(columns)
 	for _, stack := range stacks {
-		ts := ""
-		if stack.CreationTime != nil {
-			ts = stack.CreationTime.Format("2006-01-02 15:04:05")
-		}
-		table.Rows = append(table.Rows, v1.TableRow{
-			Cells: []interface{}{
-				getValue(stack.StackName),
-				string(stack.StackStatus),
-				ts,
-				getValue(stack.TemplateDescription),
-			},
-		})
+		var cells []interface{}
+		if opts.location {
+			account, region := stackLocation(getValue(stack.StackId))
+			cells = append(cells, account, region)
+		}
+		cells = append(cells, opts.tree[getValue(stack.StackId)]+getValue(stack.StackName))
+		if parent {
+			cells = append(cells, stackNameFromID(getValue(stack.ParentId)))
+		}
+		cells = append(cells,
+			string(stack.StackStatus),
+			formatTime(stack.CreationTime),
+			formatTime(stack.LastUpdatedTime),
+			formatAge(stack.CreationTime),
+		)
+		if wide {
+			drift := ""
+			if stack.DriftInformation != nil {
+				drift = string(stack.DriftInformation.StackDriftStatus)
+			}
+			cells = append(cells, drift)
+		}
+		for _, key := range opts.showTags {
+			cells = append(cells, opts.tags[getValue(stack.StackId)][key])
+		}
+		cells = append(cells, getValue(stack.TemplateDescription))
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
 	}
 	printer := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHdrs})
 	if err := printer.PrintObj(table, os.Stdout); err != nil {
-		fatalf("error printing table: %v\n", err)
+		return fmt.Errorf("error printing table: %w", err)
 	}
+	return nil
 }
 
-func printEvents(noHdrs bool, events []types.StackEvent) {
-	table := makeTable([]string{"TIMESTAMP", "LOGICAL ID", "TYPE", "STATUS", "REASON"})
+func printEvents(noHdrs bool, events []types.StackEvent, showStack bool) error {
+	wide := isWideOutput()
+	columns := []string{"TIMESTAMP"}
+	if showStack {
+		columns = append(columns, "STACK")
+	}
+	columns = append(columns, "LOGICAL ID")
+	if wide {
+		columns = append(columns, "PHYSICAL ID")
+	}
+	columns = append(columns, "TYPE", "STATUS", "REASON")
+	table := makeTable(columns)
 	for _, e := range events {
-		ts := ""
-		if e.Timestamp != nil {
-			ts = e.Timestamp.Format("2006-01-02 15:04:05")
-		}
-		table.Rows = append(table.Rows, v1.TableRow{
-			Cells: []interface{}{
-				ts,
-				getValue(e.LogicalResourceId),
-				getValue(e.ResourceType),
-				string(e.ResourceStatus),
-				getValue(e.ResourceStatusReason),
-			},
-		})
+		cells := []interface{}{formatTime(e.Timestamp)}
+		if showStack {
+			cells = append(cells, getValue(e.StackName))
+		}
+		cells = append(cells, getValue(e.LogicalResourceId))
+		if wide {
+			cells = append(cells, getValue(e.PhysicalResourceId))
+		}
+		cells = append(cells,
+			getValue(e.ResourceType),
+			string(e.ResourceStatus),
+			getValue(e.ResourceStatusReason),
+		)
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
 	}
-	printer := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHdrs})
-	if err := printer.PrintObj(table, os.Stdout); err != nil {
-		fatalf("error printing table: %v\n", err)
+	// The table is laid out first so that statuses can be coloured where the
+	// header shows the STATUS column starts
+	var buf bytes.Buffer
+	printer := printers.NewTablePrinter(printers.PrintOptions{})
+	if err := printer.PrintObj(table, &buf); err != nil {
+		return fmt.Errorf("error printing table: %w", err)
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	column := utf8.RuneCountInString(lines[0][:strings.Index(lines[0], "STATUS")])
+	// Reasons spanning several lines would throw the rows off
+	color := colorEnabled(os.Stdout) && len(lines) == len(events)+2
+	if !noHdrs {
+		fmt.Print(lines[0])
 	}
+	for i, line := range lines[1:] {
+		if !color || i == len(events) {
+			fmt.Print(line)
+			continue
+		}
+		status := string(events[i].ResourceStatus)
+		r := []rune(line)
+		end := column + utf8.RuneCountInString(status)
+		fmt.Print(string(r[:column]), colorize(status, statusColor(status), true), string(r[end:]))
+	}
+	return nil
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 func getValue(s *string) string {
@@ -241,9 +423,81 @@ func getValue(s *string) string {
 	return *s
 }
 
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// now is the clock used for ages. Tests replace it.
+var now = time.Now
+
+// formatAge renders the time elapsed since t like kubectl's AGE column, e.g.
+// "3d4h" or "92d".
+func formatAge(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return duration.HumanDuration(now().Sub(*t))
+}
+
+// parseAge parses a duration that, on top of time.ParseDuration units, accepts
+// days and weeks: "90d", "2w", "1d12h".
+func parseAge(s string) (time.Duration, error) {
+	rest := strings.TrimSpace(s)
+	var total time.Duration
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) || (rest[i] != 'd' && rest[i] != 'w') {
+			break
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		unit := 24 * time.Hour
+		if rest[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q (e.g. 90d, 2w, 36h)", s)
+		}
+		total += d
+	}
+	if total < 0 {
+		return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+	}
+	return total, nil
+}
+
+// parseTimeBound parses a --since/--until value: either an age relative to
+// now as accepted by parseAge ("2h", "3d"), or an RFC 3339 timestamp, a
+// "2006-01-02 15:04:05" time or a "2006-01-02" date, in UTC.
+func parseTimeBound(s string) (time.Time, error) {
+	if d, err := parseAge(s); err == nil {
+		return now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (e.g. 2h, 3d, 2024-06-15 or 2024-06-15T08:30:00Z)", s)
+}
+
 func truncate(s string, n int) string {
-	if len(s) <= n {
+	r := []rune(s)
+	if len(r) <= n {
 		return s
 	}
-	return s[:n-1] + "…"
+	return string(r[:n-1]) + "…"
 }
@@ -3,14 +3,15 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -19,26 +20,93 @@ var (
 	filterDeleted    bool
 	filterInProgress bool
 	ignoreCase       bool
-	nameFilter       string
+	nameFilters      []string
+	nameRegex        bool
+	excludeNames     []string
 	descContains     string
 	descNotContains  string
 	namesOnly        bool
 	resourceType     string
 	resourceName     string
 	properties       []string
+	regions          []string
+	allRegions       bool
+	profiles         []string
+	concurrency      int
+	showMatches      bool
+	strictSearch     bool
+	tagFilterExprs   []string
+	showTags         []string
+	sortBy           string
+	reverseSort      bool
+	olderThan        string
+	newerThan        string
+	topLevelOnly     bool
+	treeView         bool
 )
 
+// stackList is the structured (--output json|yaml) form of a stack listing.
+type stackList struct {
+	StackSummaries []types.StackSummary
+	Skipped        []skippedStack `json:",omitempty"`
+}
+
+func (l stackList) listItems() []any { return toItems(l.StackSummaries) }
+
+// resourceMatch is a template resource that satisfied a resource search,
+// joined with the live resource when the stack still has it.
+type resourceMatch struct {
+	StackName          string
+	StackId            string
+	LogicalResourceId  string
+	ResourceType       string
+	MatchedProperties  map[string]interface{} `json:",omitempty"`
+	PhysicalResourceId string                 `json:",omitempty"`
+	ResourceStatus     types.ResourceStatus   `json:",omitempty"`
+}
+
+// matchList is the structured (--output json|yaml) form of --show-matches.
+type matchList struct {
+	Matches []resourceMatch
+	Skipped []skippedStack `json:",omitempty"`
+}
+
+func (l matchList) listItems() []any { return toItems(l.Matches) }
+
 func ListCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "list [name-filter]",
+		Use:   "list [name-filter...]",
 		Short: "List CloudFormation stacks",
 		Long: `List CloudFormation stacks. By default shows active and in-progress stacks.
 
-A name filter can be provided as a positional argument.
+Name filters can be provided as positional arguments; a stack is listed if
+any of them matches. A plain filter matches anywhere in the name, a glob
+(prod-*-api, *-worker, dev-??-*) must match the whole name, and with --regex
+filters are regular expressions. --exclude drops stacks matching a pattern,
+using the same rules.
 
 When resource filters (--type, --resource-name, --property) are specified, 
 performs a deep search of stack templates and shows matching resources.
 
+Property filters take a path and an operator. Paths use dots for nested
+properties and [N] or [*] to index lists, e.g. Tags[*].Key or
+Policies[0].PolicyName. Operators:
+  PATH=VALUE        equal (numbers and booleans are compared as text)
+  PATH!=VALUE       present and not equal
+  PATH~=REGEX       matches a regular expression
+  PATH>N, PATH<N    numeric comparison (also >= and <=)
+  "PATH exists"     the property is set
+  "PATH !exists"    the property is not set
+Ref, Fn::Sub, Fn::GetAtt and Fn::ImportValue values are compared by their
+argument, so BucketName=${AWS::StackName}-logs matches a Fn::Sub and
+VpcId=Vpc matches a Ref. With a [*] wildcard any element may match.
+
+Stacks whose template cannot be searched (access denied, throttled, parse
+error, empty template) are listed on stderr and under "Skipped" in --output
+json|yaml. With --strict the command then fails, with the exit code of the
+first failure. Likewise, stacks whose tags cannot be read for --tag or
+--show-tag are listed on stderr, and fail the command under --strict.
+
 Examples:
   # List all stacks (table view)
   cfn list
@@ -46,6 +114,22 @@ Examples:
   # Filter stacks by name
   cfn list my-stack
   
+  # Glob, several filters and exclusions
+  cfn list 'prod-*-api' 'staging-*-api' --exclude '*-canary'
+
+  # Regular expressions
+  cfn list --regex '^(prod|staging)-[a-z]+-api$'
+
+  # Stacks owned by a team in production, showing who owns them
+  cfn list --tag Owner=team-api --tag Environment=prod --show-tag Owner
+
+  # Stale stacks, least recently changed first
+  cfn list --older-than 90d --sort-by updated
+
+  # Nested stacks beneath their root stack, or only the root stacks
+  cfn list my-app --tree
+  cfn list --top-level-only
+
   # Search for stacks containing a specific resource type
   cfn list --type AWS::ServiceCatalog::CloudFormationProvisionedProduct
   
@@ -53,9 +137,25 @@ Examples:
   cfn list --resource-name MyBucket
   
   # Combine filters
-  cfn list my-stack --type AWS::S3::Bucket --property BucketName=foo`,
-		Args: cobra.MaximumNArgs(1),
-		Run:  runList,
+  cfn list my-stack --type AWS::S3::Bucket --property BucketName=foo
+
+  # Find Lambda functions on old Python runtimes or with too little memory
+  cfn list --type AWS::Lambda::Function --property 'Runtime~=^python3\.[0-8]$'
+  cfn list --type AWS::Lambda::Function --property 'MemorySize<256'
+
+  # Find buckets without encryption, or tagged resources without an Owner tag
+  cfn list --type AWS::S3::Bucket --property 'BucketEncryption !exists'
+  cfn list --property 'Tags[*].Key!=Owner'
+
+  # Show every matching resource with its physical ID and status
+  cfn list --type AWS::S3::Bucket --property VersioningConfiguration.Status=Suspended --show-matches
+
+  # Fail unless every stack's template could be searched
+  cfn list --type AWS::S3::Bucket --strict
+
+  # Find a stack across accounts and regions
+  cfn list legacy-app --profiles dev,prod --all-regions`,
+		RunE: runList,
 	}
 
 	cmd.Flags().BoolVarP(&filterAll, "all", "A", false, "Show all stacks (overrides other status filters)")
@@ -63,27 +163,79 @@ Examples:
 	cmd.Flags().BoolVarP(&filterDeleted, "deleted", "D", false, "Filter deleted stacks (DELETE_* statuses)")
 	cmd.Flags().BoolVarP(&filterInProgress, "in-progress", "P", false, "Filter in-progress stacks (*_IN_PROGRESS statuses)")
 	cmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Use case-insensitive matching for text filters")
+	cmd.Flags().BoolVarP(&nameRegex, "regex", "E", false, "Treat name filters and --exclude patterns as regular expressions")
+	cmd.Flags().StringArrayVar(&excludeNames, "exclude", nil, "Exclude stacks whose name matches this pattern (repeatable)")
 	cmd.Flags().StringVar(&descContains, "desc", "", "Filter stacks whose description contains this string")
 	cmd.Flags().StringVar(&descNotContains, "no-desc", "", "Exclude stacks whose description contains this string")
-	cmd.Flags().BoolVarP(&namesOnly, "names-only", "1", false, "Print only stack names, one per line")
+	cmd.Flags().StringArrayVar(&tagFilterExprs, "tag", nil, "Filter stacks by tag: key=value, or key for any value (repeatable, all must match)")
+	cmd.Flags().StringSliceVar(&showTags, "show-tag", nil, "Add a column with the value of this tag (comma-separated or repeatable)")
+	cmd.Flags().StringVar(&sortBy, "sort-by", "", "Sort stacks by name, created, updated or status (default: API order)")
+	cmd.Flags().BoolVar(&reverseSort, "reverse", false, "Reverse the sort order")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Only stacks not created or updated within this duration (e.g. 90d, 2w, 36h)")
+	cmd.Flags().StringVar(&newerThan, "newer-than", "", "Only stacks created or updated within this duration (e.g. 7d)")
+	cmd.Flags().BoolVar(&topLevelOnly, "top-level-only", false, "Hide nested stacks")
+	cmd.Flags().BoolVar(&treeView, "tree", false, "Show nested stacks indented beneath their parent")
+	cmd.Flags().BoolVarP(&namesOnly, "names-only", "1", false, "Print only stack names, one per line (same as --output name)")
 	cmd.Flags().StringVarP(&resourceType, "type", "t", "", "Search for resource type (e.g., AWS::S3::Bucket)")
 	cmd.Flags().StringVarP(&resourceName, "resource-name", "n", "", "Search for resource logical ID")
-	cmd.Flags().StringArrayVarP(&properties, "property", "p", []string{}, "Search for resource property (format: key=value or nested.key=value)")
+	cmd.Flags().StringArrayVarP(&properties, "property", "p", []string{}, "Search for resource property: PATH=VALUE, PATH!=VALUE, PATH~=REGEX, PATH>N, PATH<N, \"PATH exists\" or \"PATH !exists\" (repeatable, all must match)")
+	cmd.Flags().BoolVar(&showMatches, "show-matches", false, "With resource filters, print one row per matching resource instead of one per stack")
+	cmd.Flags().BoolVar(&strictSearch, "strict", false, "With resource filters, fail if any stack could not be searched (access denied, throttled, unparseable template...); with --tag or --show-tag, fail if any stack's tags could not be read")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultConcurrency, "Number of stack templates (or, with --tag and --show-tag, stack descriptions) to fetch in parallel")
+	cmd.Flags().StringSliceVar(&regions, "regions", nil, "Query these regions concurrently (comma-separated, overrides --region)")
+	cmd.Flags().BoolVar(&allRegions, "all-regions", false, "Query every region enabled by default")
+	cmd.Flags().StringSliceVar(&profiles, "profiles", nil, "Query these AWS profiles concurrently (comma-separated, overrides --profile)")
 
 	return cmd
 }
 
-func runList(cmd *cobra.Command, args []string) {
-	// Positional arg is the stack name filter
-	if len(args) > 0 {
-		nameFilter = args[0]
+func runList(cmd *cobra.Command, args []string) error {
+	// Positional args are the stack name filters
+	nameFilters = args
+	names, err := newNameMatcher(nameFilters, excludeNames, nameRegex, ignoreCase)
+	if err != nil {
+		return err
+	}
+	tagFilters, err := parseTagFilters(tagFilterExprs)
+	if err != nil {
+		return err
+	}
+	if err := validateSortKey(sortBy); err != nil {
+		return err
+	}
+	if treeView && topLevelOnly {
+		return fmt.Errorf("--tree and --top-level-only are mutually exclusive")
+	}
+	var minAge, maxAge time.Duration
+	if olderThan != "" {
+		if minAge, err = parseAge(olderThan); err != nil {
+			return fmt.Errorf("--older-than: %w", err)
+		}
+	}
+	if newerThan != "" {
+		if maxAge, err = parseAge(newerThan); err != nil {
+			return fmt.Errorf("--newer-than: %w", err)
+		}
+	}
+	// --names-only is shorthand for --output name
+	if outputFormat == outputName {
+		namesOnly = true
+	}
+
+	targets, err := buildTargets(profiles, regions, allRegions)
+	if err != nil {
+		return err
 	}
+	// Add ACCOUNT and REGION columns when results may come from several places
+	tableOpts := stackTableOptions{location: len(targets) > 1}
 
 	ctx := context.Background()
-	client := mustClient(ctx)
 
 	// Check if resource search is requested
 	isResourceSearch := resourceType != "" || resourceName != "" || len(properties) > 0
+	if isResourceSearch && treeView {
+		return fmt.Errorf("--tree cannot be combined with resource filters")
+	}
 
 	// For resource search, default to all stacks unless user specifies status filters
 	statusFilters := buildStatusFilters(filterAll, filterComplete, filterDeleted, filterInProgress)
@@ -92,51 +244,95 @@ func runList(cmd *cobra.Command, args []string) {
 		statusFilters = nil
 	}
 
-	stacks, err := listStacks(ctx, client, statusFilters, nameFilter, descContains, descNotContains, ignoreCase)
+	refs, err := listStacksAcross(ctx, targets, statusFilters, names, descContains, descNotContains, ignoreCase)
 	if err != nil {
-		fatalf("failed to list stacks: %v\n", err)
+		return err
+	}
+
+	refs = filterByAge(refs, minAge, maxAge)
+	if topLevelOnly {
+		refs = topLevelStacks(refs)
+	}
+	sortStackRefs(refs, sortBy, reverseSort)
+
+	// ListStacks has no tags, so describe the stacks only when they're needed
+	if len(tagFilters) > 0 || len(showTags) > 0 {
+		if err := attachStackTags(ctx, refs, concurrency, strictSearch); err != nil {
+			return err
+		}
+		refs = filterByTags(refs, tagFilters, ignoreCase)
+		tableOpts.showTags = showTags
+		tableOpts.tags = stackTagsByID(refs)
 	}
 
 	if isResourceSearch {
-		runResourceSearch(ctx, client, stacks, namesOnly)
-		return
+		return runResourceSearch(ctx, refs, namesOnly, tableOpts)
+	}
+
+	if treeView {
+		refs, tableOpts.tree = treeOrder(refs)
+	}
+
+	stacks := stackSummaries(refs)
+
+	if isObjectOutput() {
+		if err := printObject(stackList{StackSummaries: append([]types.StackSummary{}, stacks...)}); err != nil {
+			return err
+		}
+		if len(stacks) == 0 {
+			return errNoStacks
+		}
+		return nil
 	}
 
 	if namesOnly {
-		for _, s := range stacks {
-			if s.StackName != nil {
-				fmt.Println(*s.StackName)
-			}
+		printNames(stackNames(stacks))
+		if len(stacks) == 0 {
+			return errNoStacks
 		}
-		return
+		return nil
 	}
 
 	if len(stacks) == 0 {
-		fmt.Fprintf(os.Stderr, "No stacks found\n")
-		os.Exit(1)
+		return errNoStacks
 	}
 
-	printStacks(noHeaders, stacks)
+	return printStacks(noHeaders, stacks, tableOpts)
 }
 
-func runResourceSearch(ctx context.Context, client *cloudformation.Client, stacks []types.StackSummary, namesOnly bool) {
+var errNoStacks = withExitCode(exitNoMatches, errors.New("no stacks found"))
+
+func stackNames(stacks []types.StackSummary) []string {
+	names := make([]string, 0, len(stacks))
+	for _, s := range stacks {
+		names = append(names, getValue(s.StackName))
+	}
+	return names
+}
+
+func runResourceSearch(ctx context.Context, stacks []stackRef, namesOnly bool, tableOpts stackTableOptions) error {
 	// Parse property filters
-	propertyFilters := make(map[string]string)
+	propertyFilters := make([]propertyFilter, 0, len(properties))
 	for _, prop := range properties {
-		parts := strings.SplitN(prop, "=", 2)
-		if len(parts) != 2 {
-			fatalf("invalid property format %q, expected key=value\n", prop)
+		f, err := parsePropertyFilter(prop, ignoreCase)
+		if err != nil {
+			return err
 		}
-		propertyFilters[parts[0]] = parts[1]
+		propertyFilters = append(propertyFilters, f)
+	}
+
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
 	}
 
 	if len(stacks) == 0 {
-		fmt.Fprintf(os.Stderr, "No stacks to search\n")
-		os.Exit(1)
+		return withExitCode(exitNoMatches, errors.New("no stacks to search"))
 	}
 
-	// Build search message (only show if not in names-only mode)
-	if !namesOnly {
+	// Build search message (only show for the table views)
+	quiet := namesOnly || isObjectOutput()
+	var progress *searchProgress
+	if !quiet {
 		searchMsg := fmt.Sprintf("Searching %d stacks for", len(stacks))
 		if resourceName != "" && resourceType != "" {
 			searchMsg += fmt.Sprintf(" resource %q of type %q", resourceName, resourceType)
@@ -149,101 +345,133 @@ func runResourceSearch(ctx context.Context, client *cloudformation.Client, stack
 		}
 		if len(propertyFilters) > 0 {
 			searchMsg += " with properties:"
-			for key, value := range propertyFilters {
-				searchMsg += fmt.Sprintf(" %s=%q", key, value)
+			for _, f := range propertyFilters {
+				searchMsg += fmt.Sprintf(" %q", f)
 			}
 		}
 		searchMsg += "..."
-		fmt.Fprintf(os.Stderr, "%s\n", searchMsg)
+		progress = newSearchProgress(searchMsg, len(stacks))
 	}
 
 	// Find stacks with matching resources
-	var matchingStackSummaries []types.StackSummary
-	for _, stack := range stacks {
-		if stack.StackName == nil {
-			continue
+	search := func(ctx context.Context, ref stackRef) ([]resourceMatch, error) {
+		if ref.summary.StackName == nil {
+			return nil, nil
+		}
+		matches, err := findStackMatches(ctx, ref.client, ref.summary, resourceType, resourceName, propertyFilters, ignoreCase, showMatches)
+		if err != nil || !showMatches || len(matches) == 0 {
+			return matches, err
 		}
+		return matches, attachLiveResources(ctx, ref.client, ref.summary, matches)
+	}
+	var onDone func(int)
+	if progress != nil {
+		onDone = progress.update
+	}
+	results := searchStacks(ctx, stacks, concurrency, search, onDone)
+	if progress != nil {
+		progress.finish()
+	}
 
-		hasMatch, err := searchStackTemplate(ctx, client, *stack.StackName, resourceType, resourceName, propertyFilters, ignoreCase)
-		if err != nil {
-			// Skip stacks we can't access
+	var matchingStackSummaries []types.StackSummary
+	var matches []resourceMatch
+	var skipped []skippedStack
+	for i, result := range results {
+		if result.err != nil {
+			skipped = append(skipped, newSkippedStack(stacks[i].summary, result.err))
 			continue
 		}
-
-		if hasMatch {
-			matchingStackSummaries = append(matchingStackSummaries, stack)
+		if len(result.matches) > 0 {
+			matchingStackSummaries = append(matchingStackSummaries, stacks[i].summary)
+			matches = append(matches, result.matches...)
+		}
+	}
+	reportSkipped(skipped, len(stacks), tableOpts.location)
+
+	var printErr error
+	switch {
+	case showMatches:
+		printErr = printMatches(matchList{Matches: append([]resourceMatch{}, matches...), Skipped: skipped}, namesOnly, tableOpts)
+	case isObjectOutput():
+		if err := printObject(stackList{StackSummaries: append([]types.StackSummary{}, matchingStackSummaries...), Skipped: skipped}); err != nil {
+			return err
+		}
+		if len(matchingStackSummaries) == 0 {
+			printErr = errNoStacks
 		}
+	default:
+		printErr = printSearchResults(matchingStackSummaries, propertyFilters, namesOnly, tableOpts)
 	}
 
-	// Clear the "Searching..." line (only if we showed it)
-	if !namesOnly {
-		fmt.Fprintf(os.Stderr, "\033[1A\033[2K")
+	// Incomplete coverage outranks "no matches" under --strict
+	if strictSearch && len(skipped) > 0 && (printErr == nil || ExitCode(printErr) == exitNoMatches) {
+		return errIncompleteSearch(skipped, len(stacks))
 	}
+	return printErr
+}
 
+// printSearchResults prints the stacks that matched a resource search.
+func printSearchResults(matchingStackSummaries []types.StackSummary, propertyFilters []propertyFilter, namesOnly bool, tableOpts stackTableOptions) error {
 	if len(matchingStackSummaries) == 0 {
-		if !namesOnly {
-			fmt.Printf("No stacks found containing")
-			if resourceName != "" && resourceType != "" {
-				fmt.Printf(" resource %q of type %q", resourceName, resourceType)
-			} else if resourceName != "" {
-				fmt.Printf(" resource %q", resourceName)
-			} else if resourceType != "" {
-				fmt.Printf(" resources of type %q", resourceType)
-			}
-			if len(propertyFilters) > 0 {
-				fmt.Printf(" with properties:")
-				for key, value := range propertyFilters {
-					fmt.Printf(" %s=%q", key, value)
-				}
+		msg := "no stacks found containing"
+		if resourceName != "" && resourceType != "" {
+			msg += fmt.Sprintf(" resource %q of type %q", resourceName, resourceType)
+		} else if resourceName != "" {
+			msg += fmt.Sprintf(" resource %q", resourceName)
+		} else if resourceType != "" {
+			msg += fmt.Sprintf(" resources of type %q", resourceType)
+		}
+		if len(propertyFilters) > 0 {
+			msg += " with properties:"
+			for _, f := range propertyFilters {
+				msg += fmt.Sprintf(" %q", f)
 			}
-			fmt.Println()
 		}
-		os.Exit(1)
+		return withExitCode(exitNoMatches, errors.New(msg))
 	}
 
 	// Print results using the same format as regular list
 	if namesOnly {
-		for _, stack := range matchingStackSummaries {
-			if stack.StackName != nil {
-				fmt.Println(*stack.StackName)
-			}
-		}
-	} else {
-		printStacks(noHeaders, matchingStackSummaries)
+		printNames(stackNames(matchingStackSummaries))
+		return nil
 	}
+	return printStacks(noHeaders, matchingStackSummaries, tableOpts)
 }
 
-func searchStackTemplate(ctx context.Context, client *cloudformation.Client, stackName, resType, resName string, propertyFilters map[string]string, ignoreCase bool) (bool, error) {
+// findStackMatches returns the resources of a stack's template that satisfy
+// the filters, ordered by logical ID. Unless all is set it stops at the first.
+func findStackMatches(ctx context.Context, client cloudFormationAPI, stack types.StackSummary, resType, resName string, propertyFilters []propertyFilter, ignoreCase, all bool) ([]resourceMatch, error) {
 	// Get template
-	output, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
-		StackName:     &stackName,
-		TemplateStage: types.TemplateStageOriginal,
-	})
+	key := templateKeyFor(stack.StackId, stack.CreationTime, stack.LastUpdatedTime, stack.StackStatus)
+	body, err := getTemplateBody(ctx, client, stackNameOrID(stack), key)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	body := getValue(output.TemplateBody)
 	if body == "" {
-		return false, fmt.Errorf("empty template")
+		return nil, errEmptyTemplate
 	}
 
-	// Parse template (try JSON first, then YAML)
-	var template map[string]interface{}
-	if err := json.Unmarshal([]byte(body), &template); err != nil {
-		// Try YAML
-		if err := yaml.Unmarshal([]byte(body), &template); err != nil {
-			return false, fmt.Errorf("failed to parse template: %v", err)
-		}
+	template, err := parseTemplate(body)
+	if err != nil {
+		return nil, err
 	}
 
 	// Search for resources
 	resources, ok := template["Resources"].(map[string]interface{})
 	if !ok {
-		return false, nil
+		return nil, nil
 	}
 
-	for logicalID, resourceData := range resources {
+	logicalIDs := make([]string, 0, len(resources))
+	for logicalID := range resources {
+		logicalIDs = append(logicalIDs, logicalID)
+	}
+	sort.Strings(logicalIDs)
+
+	var matches []resourceMatch
+	for _, logicalID := range logicalIDs {
+		resourceData := resources[logicalID]
 		// Check resource name first (cheapest check) if specified
 		if resName != "" && !containsWithCase(logicalID, resName, ignoreCase) {
 			continue
@@ -253,6 +481,7 @@ func searchStackTemplate(ctx context.Context, client *cloudformation.Client, sta
 		if !ok {
 			continue
 		}
+		var matchedProps map[string]interface{}
 
 		// Check resource type second if specified
 		if resType != "" {
@@ -264,78 +493,160 @@ func searchStackTemplate(ctx context.Context, client *cloudformation.Client, sta
 
 		// Check if properties match
 		if len(propertyFilters) > 0 {
-			properties, ok := resourceMap["Properties"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			matched, _ := checkProperties(properties, propertyFilters, ignoreCase)
+			// A resource without Properties can still satisfy "!exists"
+			properties, _ := resourceMap["Properties"].(map[string]interface{})
+			matched, props := checkProperties(properties, propertyFilters, ignoreCase)
 			if !matched {
 				continue
 			}
+			matchedProps = props
 		}
 
 		// Found a match
-		return true, nil
+		currentType, _ := resourceMap["Type"].(string)
+		matches = append(matches, resourceMatch{
+			StackName:         getValue(stack.StackName),
+			StackId:           getValue(stack.StackId),
+			LogicalResourceId: logicalID,
+			ResourceType:      currentType,
+			MatchedProperties: matchedProps,
+		})
+		if !all {
+			break
+		}
 	}
 
-	return false, nil
+	return matches, nil
 }
 
-func checkProperties(properties map[string]interface{}, filters map[string]string, ignoreCase bool) (bool, map[string]interface{}) {
-	matchedProps := make(map[string]interface{})
+// stackNameOrID prefers the stack ID, as deleted stacks can only be
+// addressed by ID.
+func stackNameOrID(stack types.StackSummary) string {
+	if id := getValue(stack.StackId); id != "" {
+		return id
+	}
+	return getValue(stack.StackName)
+}
 
-	for key, expectedValue := range filters {
-		// Handle nested properties (e.g., "Versioning.Status")
-		value := getNestedProperty(properties, key, ignoreCase)
-		if value == nil {
-			return false, nil
+// attachLiveResources fills in the physical ID and status of each match from
+// the stack's current resources. Resources that no longer exist, e.g. in
+// deleted stacks, are left without them.
+func attachLiveResources(ctx context.Context, client cloudFormationAPI, stack types.StackSummary, matches []resourceMatch) error {
+	live, err := listStackResources(ctx, client, stackNameOrID(stack))
+	if err != nil {
+		if stack.StackStatus == types.StackStatusDeleteComplete {
+			return nil
 		}
+		return err
+	}
 
-		// Convert value to string for comparison
-		valueStr := fmt.Sprintf("%v", value)
-		if !equalsWithCase(valueStr, expectedValue, ignoreCase) {
-			return false, nil
+	byLogicalID := make(map[string]types.StackResourceSummary, len(live))
+	for _, r := range live {
+		byLogicalID[getValue(r.LogicalResourceId)] = r
+	}
+	for i := range matches {
+		if r, ok := byLogicalID[matches[i].LogicalResourceId]; ok {
+			matches[i].PhysicalResourceId = getValue(r.PhysicalResourceId)
+			matches[i].ResourceStatus = r.ResourceStatus
 		}
-
-		matchedProps[key] = value
 	}
-
-	return true, matchedProps
+	return nil
 }
 
-func getNestedProperty(properties map[string]interface{}, path string, ignoreCase bool) interface{} {
-	parts := strings.Split(path, ".")
-	var current interface{} = properties
-
-	for _, part := range parts {
-		currentMap, ok := current.(map[string]interface{})
-		if !ok {
-			return nil
+func printMatches(list matchList, namesOnly bool, tableOpts stackTableOptions) error {
+	matches := list.Matches
+	switch {
+	case isObjectOutput():
+		if err := printObject(list); err != nil {
+			return err
 		}
-
-		if ignoreCase {
-			found := false
-			for key, value := range currentMap {
-				if strings.EqualFold(key, part) {
-					current = value
-					found = true
-					break
-				}
+	case namesOnly:
+		// The physical ID is what scripts need; fall back to the logical ID
+		for _, m := range matches {
+			if m.PhysicalResourceId != "" {
+				fmt.Println(m.PhysicalResourceId)
+			} else {
+				fmt.Println(m.LogicalResourceId)
 			}
-			if !found {
-				return nil
+		}
+	default:
+		if len(matches) == 0 {
+			break
+		}
+		columns := []string{"STACK", "LOGICAL ID", "TYPE", "PHYSICAL ID", "STATUS", "MATCHED"}
+		if tableOpts.location {
+			columns = append([]string{"ACCOUNT", "REGION"}, columns...)
+		}
+		table := makeTable(columns)
+		for _, m := range matches {
+			var cells []interface{}
+			if tableOpts.location {
+				account, region := stackLocation(m.StackId)
+				cells = append(cells, account, region)
 			}
-			continue
+			cells = append(cells,
+				m.StackName,
+				m.LogicalResourceId,
+				m.ResourceType,
+				m.PhysicalResourceId,
+				string(m.ResourceStatus),
+				formatMatchedProperties(m.MatchedProperties),
+			)
+			table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
+		}
+		if err := printTable(table); err != nil {
+			return err
 		}
+	}
 
-		val, exists := currentMap[part]
-		if !exists {
-			return nil
+	if len(matches) == 0 {
+		return withExitCode(exitNoMatches, errors.New("no matching resources found"))
+	}
+	return nil
+}
+
+// formatMatchedProperties renders matched properties as sorted key=value
+// pairs; non-scalar values are shown as JSON.
+func formatMatchedProperties(props map[string]interface{}) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		value := props[k]
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			if data, err := json.Marshal(value); err == nil {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", k, data))
+				continue
+			}
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, value))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func checkProperties(properties map[string]interface{}, filters []propertyFilter, ignoreCase bool) (bool, map[string]interface{}) {
+	matchedProps := make(map[string]interface{})
+
+	for _, f := range filters {
+		matched, values := f.match(properties, ignoreCase)
+		if !matched {
+			return false, nil
 		}
 
-		current = val
+		// Wildcards can match several values; show them all
+		switch len(values) {
+		case 0:
+		case 1:
+			matchedProps[f.pathString()] = values[0]
+		default:
+			matchedProps[f.pathString()] = values
+		}
 	}
 
-	return current
+	return true, matchedProps
 }
@@ -0,0 +1,494 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestListStacksFilters(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusFilters   []string
+		all             bool
+		complete        bool
+		deleted         bool
+		inProgress      bool
+		nameFilters     []string
+		exclude         []string
+		regex           bool
+		descContains    string
+		descNotContains string
+		ignoreCase      bool
+		want            []string
+	}{
+		{
+			name: "default shows active and in-progress stacks",
+			want: []string{"prod-network-vpc", "prod-app-api", "dev-app-api", "prod-app-worker"},
+		},
+		{
+			name:        "name filter is a substring match",
+			nameFilters: []string{"app-api"},
+			want:        []string{"prod-app-api", "dev-app-api"},
+		},
+		{
+			name:        "name filter is case sensitive by default",
+			nameFilters: []string{"PROD"},
+			want:        nil,
+		},
+		{
+			name:        "ignore case",
+			nameFilters: []string{"PROD"},
+			ignoreCase:  true,
+			want:        []string{"prod-network-vpc", "prod-app-api", "prod-app-worker"},
+		},
+		{
+			name:         "description contains",
+			descContains: "Production",
+			want:         []string{"prod-network-vpc", "prod-app-api", "prod-app-worker"},
+		},
+		{
+			name:            "description does not contain",
+			descNotContains: "API",
+			want:            []string{"prod-network-vpc", "prod-app-worker"},
+		},
+		{
+			name:       "in-progress only",
+			inProgress: true,
+			want:       []string{"prod-app-worker"},
+		},
+		{
+			name:    "deleted only",
+			deleted: true,
+			want:    []string{"legacy-app"},
+		},
+		{
+			name:        "glob must match the whole name",
+			nameFilters: []string{"prod-*-api"},
+			want:        []string{"prod-app-api"},
+		},
+		{
+			name:        "glob with character class and ignore case",
+			nameFilters: []string{"[DP]*-APP-*"},
+			ignoreCase:  true,
+			want:        []string{"prod-app-api", "dev-app-api", "prod-app-worker"},
+		},
+		{
+			name:        "several filters are OR'd",
+			nameFilters: []string{"network", "dev-*"},
+			want:        []string{"prod-network-vpc", "dev-app-api"},
+		},
+		{
+			name:        "regex",
+			nameFilters: []string{"^(dev|prod)-app-(api|worker)$"},
+			regex:       true,
+			want:        []string{"prod-app-api", "dev-app-api", "prod-app-worker"},
+		},
+		{
+			name:        "exclude",
+			nameFilters: []string{"prod"},
+			exclude:     []string{"*-worker", "network"},
+			want:        []string{"prod-app-api"},
+		},
+		{
+			name:    "exclude without include filters",
+			exclude: []string{"prod-*"},
+			want:    []string{"dev-app-api"},
+		},
+		{
+			name:        "all with name filter",
+			all:         true,
+			nameFilters: []string{"app"},
+			want:        []string{"prod-app-api", "dev-app-api", "prod-app-worker", "legacy-app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t)
+			filters := buildStatusFilters(tt.all, tt.complete, tt.deleted, tt.inProgress)
+			names, err := newNameMatcher(tt.nameFilters, tt.exclude, tt.regex, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("newNameMatcher: %v", err)
+			}
+
+			stacks, err := listStacks(context.Background(), client, filters, names, tt.descContains, tt.descNotContains, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("listStacks: %v", err)
+			}
+			if got := stackNames(stacks); !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if len(stacks) > client.pageSize && client.calls["ListStacks"] < 2 {
+				t.Errorf("expected the listing to be paginated, got %d ListStacks calls", client.calls["ListStacks"])
+			}
+		})
+	}
+}
+
+func summaryNamed(name string) types.StackSummary {
+	return types.StackSummary{StackName: aws.String(name)}
+}
+
+func TestFindStackMatchesFilters(t *testing.T) {
+	tests := []struct {
+		name       string
+		stack      string
+		resType    string
+		resName    string
+		properties []string
+		ignoreCase bool
+		want       bool
+	}{
+		{name: "type match in JSON template", stack: "prod-app-api", resType: "AWS::SQS::Queue", want: true},
+		{name: "type match in YAML template", stack: "prod-app-worker", resType: "AWS::SQS::Queue", want: true},
+		{name: "type mismatch", stack: "prod-network-vpc", resType: "AWS::SQS::Queue", want: false},
+		{name: "type is case sensitive", stack: "prod-app-api", resType: "aws::sqs::queue", want: false},
+		{name: "type ignore case", stack: "prod-app-api", resType: "aws::sqs::queue", ignoreCase: true, want: true},
+		{name: "logical ID substring", stack: "prod-network-vpc", resName: "Subnet", want: true},
+		{name: "logical ID and type must both match", stack: "prod-network-vpc", resName: "Subnet", resType: "AWS::EC2::VPC", want: false},
+		{
+			name:       "nested property",
+			stack:      "prod-app-api",
+			resType:    "AWS::S3::Bucket",
+			properties: []string{"VersioningConfiguration.Status=Enabled"},
+			want:       true,
+		},
+		{
+			name:       "nested property mismatch",
+			stack:      "dev-app-api",
+			resType:    "AWS::S3::Bucket",
+			properties: []string{"VersioningConfiguration.Status=Enabled"},
+			want:       false,
+		},
+		{
+			name:       "numeric property compared as string",
+			stack:      "prod-app-api",
+			properties: []string{"MemorySize=512"},
+			want:       true,
+		},
+		{
+			name:       "property key ignore case",
+			stack:      "prod-app-api",
+			properties: []string{"runtime=PYTHON3.8"},
+			ignoreCase: true,
+			want:       true,
+		},
+		{
+			name:       "all properties must match",
+			stack:      "prod-app-api",
+			properties: []string{"Runtime=python3.8", "MemorySize=128"},
+			want:       false,
+		},
+		{
+			name:       "wildcard over list of tags",
+			stack:      "prod-network-vpc",
+			properties: []string{"Tags[*].Key=Owner", "Tags[1].Value=platform"},
+			want:       true,
+		},
+		{
+			name:       "Fn::Sub compared by its template string",
+			stack:      "prod-network-vpc",
+			properties: []string{"Tags[*].Value=${AWS::StackName}-vpc"},
+			want:       true,
+		},
+		{
+			name:       "Ref compared by its target",
+			stack:      "prod-network-vpc",
+			resType:    "AWS::EC2::Subnet",
+			properties: []string{"VpcId=Vpc"},
+			want:       true,
+		},
+		{
+			name:       "numeric and regex operators",
+			stack:      "dev-app-api",
+			properties: []string{"MemorySize<256", `Runtime~=^python3\.1[0-9]$`},
+			want:       true,
+		},
+		{
+			name:       "not exists",
+			stack:      "prod-app-api",
+			resType:    "AWS::S3::Bucket",
+			properties: []string{"BucketEncryption !exists"},
+			want:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t)
+			var props []propertyFilter
+			for _, expr := range tt.properties {
+				f, err := parsePropertyFilter(expr, tt.ignoreCase)
+				if err != nil {
+					t.Fatalf("parsePropertyFilter(%q): %v", expr, err)
+				}
+				props = append(props, f)
+			}
+
+			matches, err := findStackMatches(context.Background(), client, summaryNamed(tt.stack), tt.resType, tt.resName, props, tt.ignoreCase, false)
+			if err != nil {
+				t.Fatalf("findStackMatches: %v", err)
+			}
+			if got := len(matches) > 0; got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindStackMatchesErrors(t *testing.T) {
+	client := newFakeClient(t)
+	client.templateErrors["prod-app-api"] = errors.New("AccessDenied")
+	client.templates["prod-app-worker"] = ""
+
+	if _, err := findStackMatches(context.Background(), client, summaryNamed("prod-app-api"), "AWS::SQS::Queue", "", nil, false, false); err == nil {
+		t.Error("expected GetTemplate error to be returned")
+	}
+	if _, err := findStackMatches(context.Background(), client, summaryNamed("prod-app-worker"), "AWS::SQS::Queue", "", nil, false, false); err == nil {
+		t.Error("expected empty template to be an error")
+	}
+}
+
+func TestRunListResourceSearch(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "name")
+	// ListCmd binds the flag variables to their defaults, so set them afterwards
+	cmd := ListCmd()
+	resourceType = "AWS::S3::Bucket"
+	t.Cleanup(func() {
+		resourceType = ""
+		namesOnly = false
+		nameFilters = nil
+	})
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, nil) })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+
+	// Resource search covers every stack, including deleted ones
+	want := "prod-app-api\ndev-app-api\nlegacy-app\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if client.calls["GetTemplate"] != 5 {
+		t.Errorf("expected 5 GetTemplate calls, got %d", client.calls["GetTemplate"])
+	}
+}
+
+// runShowMatches runs `cfn list --show-matches` with the given filters.
+func runShowMatches(t *testing.T, format, resType string, props []string) (string, error) {
+	t.Helper()
+	setOutput(t, format)
+	cmd := ListCmd()
+	resourceType = resType
+	properties = props
+	showMatches = true
+	t.Cleanup(func() {
+		resourceType = ""
+		properties = nil
+		showMatches = false
+		namesOnly = false
+	})
+
+	var err error
+	out := captureStdout(t, func() {
+		captureStderr(t, func() { err = runList(cmd, nil) })
+	})
+	return out, err
+}
+
+func TestShowMatchesTable(t *testing.T) {
+	newFakeClient(t)
+
+	out, err := runShowMatches(t, "", "AWS::S3::Bucket", nil)
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 rows:\n%s", out)
+	}
+	for _, want := range []string{"STACK", "LOGICAL ID", "PHYSICAL ID", "STATUS", "MATCHED"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("header missing %q: %s", want, lines[0])
+		}
+	}
+	if fields := strings.Fields(lines[1]); len(fields) < 5 || fields[0] != "prod-app-api" || fields[1] != "AssetsBucket" || fields[3] != "prod-app-api-assets" || fields[4] != "CREATE_COMPLETE" {
+		t.Errorf("unexpected row: %s", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "dev-app-api") || !strings.HasPrefix(lines[3], "legacy-app") {
+		t.Errorf("unexpected rows:\n%s", out)
+	}
+}
+
+func TestShowMatchesJSON(t *testing.T) {
+	newFakeClient(t)
+
+	out, err := runShowMatches(t, "json", "", []string{"Runtime=python3.8"})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	var result matchList
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(result.Matches) != 1 {
+		t.Fatalf("expected one match, got %+v", result.Matches)
+	}
+	m := result.Matches[0]
+	if m.StackName != "prod-app-api" || m.LogicalResourceId != "Handler" || m.ResourceType != "AWS::Lambda::Function" ||
+		m.PhysicalResourceId != "prod-app-api-handler" || m.ResourceStatus != "UPDATE_COMPLETE" || m.MatchedProperties["Runtime"] != "python3.8" {
+		t.Errorf("unexpected match: %+v", m)
+	}
+}
+
+func TestShowMatchesNames(t *testing.T) {
+	newFakeClient(t)
+
+	out, err := runShowMatches(t, "name", "AWS::SQS::Queue", nil)
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	// Physical IDs where the resource exists, logical IDs otherwise
+	want := "https://sqs.us-east-1.amazonaws.com/111111111111/prod-app-api-queue\nWorkQueue\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	if _, err := runShowMatches(t, "name", "AWS::SNS::Topic", nil); ExitCode(err) != exitNoMatches {
+		t.Errorf("expected no-matches exit code, got %v", err)
+	}
+}
+
+func TestFormatMatchedProperties(t *testing.T) {
+	got := formatMatchedProperties(map[string]interface{}{
+		"Runtime":    "python3.8",
+		"MemorySize": float64(512),
+		"Tags":       []interface{}{map[string]interface{}{"Key": "Owner"}},
+	})
+	want := `MemorySize=512, Runtime=python3.8, Tags=[{"Key":"Owner"}]`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// runSkippedSearch runs a --type search where three stacks cannot be
+// searched: access denied, an unparseable template and an empty one.
+func runSkippedSearch(t *testing.T, format string, strict bool) (string, string, error) {
+	t.Helper()
+	client := newFakeClient(t)
+	client.templateErrors["prod-app-api"] = &smithy.GenericAPIError{Code: "AccessDenied", Message: "not allowed"}
+	client.templates["dev-app-api"] = "Resources: [unclosed"
+	client.templates["prod-app-worker"] = ""
+
+	setOutput(t, format)
+	cmd := ListCmd()
+	resourceType = "AWS::S3::Bucket"
+	strictSearch = strict
+	t.Cleanup(func() {
+		resourceType = ""
+		strictSearch = false
+		namesOnly = false
+	})
+
+	var err error
+	var stderr string
+	stdout := captureStdout(t, func() {
+		stderr = captureStderr(t, func() { err = runList(cmd, nil) })
+	})
+	return stdout, stderr, err
+}
+
+func TestResourceSearchReportsSkippedStacks(t *testing.T) {
+	stdout, stderr, err := runSkippedSearch(t, "name", false)
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	if stdout != "legacy-app\n" {
+		t.Errorf("stdout = %q, want only the searchable match", stdout)
+	}
+	for _, want := range []string{
+		"3 of 5 stacks could not be searched",
+		"prod-app-api: access denied",
+		"dev-app-api: parse error",
+		"prod-app-worker: empty template\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestResourceSearchSkippedInStructuredOutput(t *testing.T) {
+	stdout, _, err := runSkippedSearch(t, "json", false)
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+
+	var got struct {
+		StackSummaries []types.StackSummary
+		Skipped        []skippedStack
+	}
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	reasons := make(map[string]string)
+	for _, s := range got.Skipped {
+		reasons[s.StackName] = s.Reason
+	}
+	want := map[string]string{
+		"prod-app-api":    skipAccessDenied,
+		"dev-app-api":     skipParseError,
+		"prod-app-worker": skipEmptyTemplate,
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("skipped = %v, want %v", reasons, want)
+	}
+	if len(got.StackSummaries) != 1 {
+		t.Errorf("expected 1 matching stack, got %d", len(got.StackSummaries))
+	}
+}
+
+func TestResourceSearchStrict(t *testing.T) {
+	stdout, _, err := runSkippedSearch(t, "name", true)
+	if err == nil {
+		t.Fatal("expected --strict to fail when stacks were skipped")
+	}
+	// The matches are still printed, and the exit code follows the first failure
+	if stdout != "legacy-app\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if code := ExitCode(err); code != exitAuthError {
+		t.Errorf("exit code = %d, want %d", code, exitAuthError)
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: &smithy.GenericAPIError{Code: "AccessDenied"}, want: skipAccessDenied},
+		{err: &smithy.GenericAPIError{Code: "Throttling"}, want: skipThrottled},
+		{err: &smithy.GenericAPIError{Code: "ValidationError", Message: "Stack with id x does not exist"}, want: skipNotFound},
+		{err: errEmptyTemplate, want: skipEmptyTemplate},
+		{err: fmt.Errorf("%w: bad", errTemplateParse), want: skipParseError},
+		{err: errors.New("boom"), want: skipError},
+	}
+	for _, tt := range tests {
+		if got := skipReason(tt.err); got != tt.want {
+			t.Errorf("skipReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
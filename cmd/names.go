@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nameMatcher selects stacks by name. A stack matches when any include
+// pattern matches it (or there are none) and no exclude pattern does.
+//
+// Patterns are substrings, like the original name filter, unless they contain
+// glob characters (*, ? or [...]), in which case the whole name must match:
+// "prod-*-api" matches prod-app-api but not prod-app-api-v2. With --regex they
+// are regular expressions, matched anywhere in the name unless anchored.
+type nameMatcher struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func newNameMatcher(include, exclude []string, useRegex, ignoreCase bool) (*nameMatcher, error) {
+	m := &nameMatcher{}
+	for _, p := range include {
+		re, err := compileNamePattern(p, useRegex, ignoreCase)
+		if err != nil {
+			return nil, err
+		}
+		m.include = append(m.include, re)
+	}
+	for _, p := range exclude {
+		re, err := compileNamePattern(p, useRegex, ignoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("--exclude: %w", err)
+		}
+		m.exclude = append(m.exclude, re)
+	}
+	return m, nil
+}
+
+func (m *nameMatcher) match(name string) bool {
+	if m == nil {
+		return true
+	}
+	for _, re := range m.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(m.include) == 0 {
+		return true
+	}
+	for _, re := range m.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileNamePattern(pattern string, useRegex, ignoreCase bool) (*regexp.Regexp, error) {
+	var expr string
+	switch {
+	case useRegex:
+		expr = pattern
+	case isGlob(pattern):
+		expr = globToRegexp(pattern)
+	default:
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globToRegexp translates a shell glob into an anchored regular expression.
+// "*" matches any run of characters, "?" a single one and "[...]" a class,
+// negated with a leading "!" or "^". A "]" right after the opening "[" (or
+// "[!") is part of the class, and a "[" without a closing "]" is literal.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	literal := 0 // start of the pending run of literal characters
+	flush := func(i int) {
+		b.WriteString(regexp.QuoteMeta(glob[literal:i]))
+	}
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*', '?':
+			flush(i)
+			if glob[i] == '*' {
+				b.WriteString(".*")
+			} else {
+				b.WriteByte('.')
+			}
+			literal = i + 1
+		case '[':
+			class, n, ok := globClass(glob[i+1:])
+			if !ok {
+				continue // literal, quoted with its run
+			}
+			flush(i)
+			b.WriteString(class)
+			i += n
+			literal = i + 1
+		}
+	}
+	flush(len(glob))
+	b.WriteByte('$')
+	return b.String()
+}
+
+// globClass translates the glob class following a "[" into a regular
+// expression class, returning how many bytes it took, including the closing
+// "]". ok is false when the class is not closed.
+func globClass(s string) (class string, n int, ok bool) {
+	i := 0
+	negated := i < len(s) && (s[i] == '!' || s[i] == '^')
+	if negated {
+		i++
+	}
+	start := i
+	if i < len(s) && s[i] == ']' {
+		i++ // a leading "]" is a member
+	}
+	end := strings.IndexByte(s[i:], ']')
+	if end < 0 {
+		return "", 0, false
+	}
+	end += i
+
+	var b strings.Builder
+	b.WriteByte('[')
+	if negated {
+		b.WriteByte('^')
+	}
+	for _, r := range s[start:end] {
+		switch r {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte(']')
+	return b.String(), end + 1, true
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import "testing"
+
+func TestNameMatcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		include    []string
+		exclude    []string
+		regex      bool
+		ignoreCase bool
+		stack      string
+		want       bool
+	}{
+		{name: "no patterns", stack: "anything", want: true},
+		{name: "substring", include: []string{"app"}, stack: "prod-app-api", want: true},
+		{name: "dots are literal in substrings", include: []string{"a.p"}, stack: "prod-app-api", want: false},
+		{name: "glob anchored", include: []string{"app-*"}, stack: "prod-app-api", want: false},
+		{name: "glob star", include: []string{"prod-*-api"}, stack: "prod-app-api", want: true},
+		{name: "glob question mark", include: []string{"dev-??-api"}, stack: "dev-eu-api", want: true},
+		{name: "glob negated class", include: []string{"[!d]*"}, stack: "dev-app", want: false},
+		{name: "glob unclosed bracket is literal", include: []string{"a[b*"}, stack: "a[bc", want: true},
+		{name: "glob multibyte literal", include: []string{"café-*"}, stack: "café-prod", want: true},
+		{name: "glob question mark matches a rune", include: []string{"caf?-prod"}, stack: "café-prod", want: true},
+		{name: "glob multibyte class", include: []string{"[éè]t*"}, stack: "été", want: true},
+		{name: "glob empty class is literal", include: []string{"x[]"}, stack: "x[]", want: true},
+		{name: "glob negated empty class is literal", include: []string{"x[!]"}, stack: "x[!]", want: true},
+		{name: "glob leading bracket in class", include: []string{"[]a]x"}, stack: "]x", want: true},
+		{name: "glob negated leading bracket in class", include: []string{"[!]]*"}, stack: "]x", want: false},
+		{name: "glob negated leading bracket matches others", include: []string{"[!]]*"}, stack: "x]", want: true},
+		{name: "glob case", include: []string{"PROD-*"}, ignoreCase: true, stack: "prod-app", want: true},
+		{name: "regex unanchored", include: []string{"app-(api|web)"}, regex: true, stack: "prod-app-web-2", want: true},
+		{name: "regex anchored", include: []string{"^app"}, regex: true, stack: "prod-app", want: false},
+		{name: "any include matches", include: []string{"x", "prod"}, stack: "prod-app", want: true},
+		{name: "exclude wins", include: []string{"prod"}, exclude: []string{"*-app"}, stack: "prod-app", want: false},
+		{name: "exclude regex", exclude: []string{"^prod-"}, regex: true, stack: "prod-app", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newNameMatcher(tt.include, tt.exclude, tt.regex, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("newNameMatcher: %v", err)
+			}
+			if got := m.match(tt.stack); got != tt.want {
+				t.Errorf("match(%q) = %v, want %v", tt.stack, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameMatcherInvalidRegex(t *testing.T) {
+	if _, err := newNameMatcher([]string{"("}, nil, true, false); err == nil {
+		t.Error("expected an invalid include regex to fail")
+	}
+	if _, err := newNameMatcher(nil, []string{"("}, true, false); err == nil {
+		t.Error("expected an invalid exclude regex to fail")
+	}
+}
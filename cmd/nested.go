@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// stackNameFromID extracts the stack name from a stack ARN,
+// e.g. arn:aws:cloudformation:us-east-1:123456789012:stack/name/id.
+func stackNameFromID(stackID string) string {
+	_, rest, ok := strings.Cut(stackID, ":stack/")
+	if !ok {
+		return stackID
+	}
+	name, _, _ := strings.Cut(rest, "/")
+	return name
+}
+
+func isNestedStack(s types.StackSummary) bool {
+	return getValue(s.ParentId) != ""
+}
+
+func topLevelStacks(refs []stackRef) []stackRef {
+	var kept []stackRef
+	for _, r := range refs {
+		if !isNestedStack(r.summary) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// treeOrder reorders stacks so nested stacks follow their parent, depth first,
+// keeping the existing order among siblings. It also returns the tree drawing
+// to prefix each stack's name with, by stack ID. Nested stacks whose parent is
+// not in the list are shown at the top level.
+func treeOrder(refs []stackRef) ([]stackRef, map[string]string) {
+	present := make(map[string]bool, len(refs))
+	for _, r := range refs {
+		present[getValue(r.summary.StackId)] = true
+	}
+
+	children := make(map[string][]stackRef)
+	var roots []stackRef
+	for _, r := range refs {
+		parent := getValue(r.summary.ParentId)
+		if parent == "" || !present[parent] {
+			roots = append(roots, r)
+			continue
+		}
+		children[parent] = append(children[parent], r)
+	}
+
+	ordered := make([]stackRef, 0, len(refs))
+	prefixes := make(map[string]string, len(refs))
+	var walk func(r stackRef, indent, branch string)
+	walk = func(r stackRef, indent, branch string) {
+		id := getValue(r.summary.StackId)
+		ordered = append(ordered, r)
+		prefixes[id] = indent + branch
+		kids := children[id]
+		if branch == "├─ " {
+			indent += "│  "
+		} else if branch == "└─ " {
+			indent += "   "
+		}
+		for i, c := range kids {
+			b := "├─ "
+			if i == len(kids)-1 {
+				b = "└─ "
+			}
+			walk(c, indent, b)
+		}
+	}
+	for _, r := range roots {
+		walk(r, "", "")
+	}
+	return ordered, prefixes
+}
+
+// nestedStackID returns the ID of the nested stack an event is about, or ""
+// for events of other resources and of the stack itself. The first
+// CREATE_IN_PROGRESS event of a new nested stack has no physical ID yet; the
+// next one does.
+func nestedStackID(e types.StackEvent) string {
+	if getValue(e.ResourceType) != nestedStackType || isStackEvent(e) {
+		return ""
+	}
+	return getValue(e.PhysicalResourceId)
+}
+
+// listEventsRecursive lists the events of a stack and of every stack nested in
+// it, newest first. Nested stacks are discovered from the events themselves,
+// so ones that have since been deleted are included too. The newest limit
+// events of each stack are enough to find the newest limit overall, since a
+// nested stack's events fall between its parent's events for it. With stop,
+// the stack's events are listed as by listEventsUntil and nested stacks' back
+// to the oldest of those.
+func listEventsRecursive(ctx context.Context, client cloudFormationAPI, stackName string, limit int, stop func(types.StackEvent) bool) ([]types.StackEvent, error) {
+	list := func(stackName string) ([]types.StackEvent, error) {
+		return listEvents(ctx, client, stackName, limit)
+	}
+	if stop != nil {
+		list = func(stackName string) ([]types.StackEvent, error) {
+			return listEventsUntil(ctx, client, stackName, stop)
+		}
+	}
+
+	events, err := list(stackName)
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil && len(events) > 0 {
+		oldest := eventTime(events[len(events)-1])
+		list = func(stackName string) ([]types.StackEvent, error) {
+			return listEventsSince(ctx, client, stackName, oldest)
+		}
+	}
+
+	seen := make(map[string]bool)
+	all := events
+	queue := newNestedStackIDs(events, seen)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		nested, err := list(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to list events for nested stack %q: %v\n", stackNameFromID(id), err)
+			continue
+		}
+		all = append(all, nested...)
+		queue = append(queue, newNestedStackIDs(nested, seen)...)
+	}
+
+	sortEvents(all, true)
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// newNestedStackIDs returns the nested stacks the events mention that are not
+// in seen yet, adding them to it.
+func newNestedStackIDs(events []types.StackEvent, seen map[string]bool) []string {
+	var ids []string
+	for _, e := range events {
+		if id := nestedStackID(e); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sortEvents orders events from several stacks by timestamp, keeping the
+// order of events with the same timestamp.
+func sortEvents(events []types.StackEvent, newestFirst bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := eventTime(events[i]), eventTime(events[j])
+		if newestFirst {
+			return a.After(b)
+		}
+		return a.Before(b)
+	})
+}
+
+func eventTime(e types.StackEvent) time.Time {
+	if e.Timestamp == nil {
+		return time.Time{}
+	}
+	return *e.Timestamp
+}
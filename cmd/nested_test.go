@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// runNestedList runs `cfn list` against the nested fixtures.
+func runNestedList(t *testing.T, format string, args []string, setup func()) (string, error) {
+	t.Helper()
+	newNestedFakeClient(t)
+	setOutput(t, format)
+	cmd := ListCmd()
+	setup()
+	t.Cleanup(func() {
+		topLevelOnly = false
+		treeView = false
+		namesOnly = false
+		sortBy = ""
+		nameFilters = nil
+	})
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, args) })
+	return out, err
+}
+
+func TestStackNameFromID(t *testing.T) {
+	for id, want := range map[string]string{
+		"arn:aws:cloudformation:us-east-1:111111111111:stack/shop-Database-1A2B3C/5e1f0000-0002": "shop-Database-1A2B3C",
+		"shop": "shop",
+		"":     "",
+	} {
+		if got := stackNameFromID(id); got != want {
+			t.Errorf("stackNameFromID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestListTopLevelOnly(t *testing.T) {
+	out, err := runNestedList(t, "name", []string{"shop"}, func() { topLevelOnly = true })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	if out != "shop\n" {
+		t.Errorf("got %q, want only the root stack", out)
+	}
+}
+
+func TestListParentColumn(t *testing.T) {
+	out, err := runNestedList(t, "", []string{"shop"}, func() {})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if fields := strings.Fields(lines[0]); len(fields) < 2 || fields[1] != "PARENT" {
+		t.Fatalf("expected a PARENT column, got %q", lines[0])
+	}
+	if fields := strings.Fields(lines[3]); fields[0] != "shop-Database-1A2B3C-Cache-4D5E6F" || fields[1] != "shop-Database-1A2B3C" {
+		t.Errorf("unexpected row %q", lines[3])
+	}
+
+	// Listings without nested stacks keep the usual columns
+	out, err = runNestedList(t, "", []string{"prod-app"}, func() {})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	if strings.Contains(out, "PARENT") {
+		t.Errorf("unexpected PARENT column:\n%s", out)
+	}
+}
+
+// columnGap separates table columns; the tree drawing never has three spaces
+// in a row before a name at these depths.
+var columnGap = regexp.MustCompile(`\s{3,}`)
+
+func TestListTree(t *testing.T) {
+	// Sorted by name in reverse, so the tree has to regroup the stacks
+	out, err := runNestedList(t, "", []string{"shop"}, func() {
+		treeView = true
+		sortBy = sortByName
+	})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		// Keep the drawing, drop the columns after the name
+		names = append(names, columnGap.Split(line, 2)[0])
+	}
+	want := []string{
+		"shop",
+		"├─ shop-Database-1A2B3C",
+		"│  └─ shop-Database-1A2B3C-Cache-4D5E6F",
+		"└─ shop-Network-7G8H9I",
+	}
+	if strings.Join(names, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(names, "\n"), strings.Join(want, "\n"))
+	}
+	if strings.Contains(out, "PARENT") {
+		t.Error("the tree should replace the PARENT column")
+	}
+}
+
+func TestListTreeNamesAreOrderedOnly(t *testing.T) {
+	out, err := runNestedList(t, "name", []string{"shop"}, func() {
+		treeView = true
+		sortBy = sortByName
+		reverseSort = true
+	})
+	t.Cleanup(func() { reverseSort = false })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	want := "shop\nshop-Network-7G8H9I\nshop-Database-1A2B3C\nshop-Database-1A2B3C-Cache-4D5E6F\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestListTreeFlagConflicts(t *testing.T) {
+	if _, err := runNestedList(t, "", nil, func() { treeView, topLevelOnly = true, true }); err == nil {
+		t.Error("expected --tree with --top-level-only to fail")
+	}
+	_, err := runNestedList(t, "", nil, func() {
+		treeView = true
+		resourceType = "AWS::S3::Bucket"
+	})
+	resourceType = ""
+	if err == nil {
+		t.Error("expected --tree with resource filters to fail")
+	}
+}
+
+func TestEventsRecursive(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	var err error
+	out := captureStdout(t, func() { err = runEvents("shop", eventsOptions{recursive: true}) })
+	if err != nil {
+		t.Fatalf("runEvents: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if fields := strings.Fields(lines[0]); fields[1] != "STACK" {
+		t.Fatalf("expected a STACK column, got %q", lines[0])
+	}
+	if len(lines) != 1+15+9+6+4 {
+		t.Errorf("expected the events of all four stacks, got %d lines:\n%s", len(lines), out)
+	}
+	// Failures are interleaved newest first, down to the cache cluster that caused the rollback
+	var failed []string
+	for _, line := range lines[1:] {
+		if strings.Contains(line, "UPDATE_FAILED") {
+			failed = append(failed, strings.Fields(line)[2])
+		}
+	}
+	if got, want := strings.Join(failed, ","), "shop,shop-Database-1A2B3C,shop-Database-1A2B3C,shop-Database-1A2B3C-Cache-4D5E6F"; got != want {
+		t.Errorf("got failures from %s, want %s", got, want)
+	}
+}
+
+func TestListEventsRecursiveLimit(t *testing.T) {
+	fake := newNestedFakeClient(t)
+
+	events, err := listEventsRecursive(context.Background(), fake, "shop", 5, nil)
+	if err != nil {
+		t.Fatalf("listEventsRecursive: %v", err)
+	}
+	all, err := listEventsRecursive(context.Background(), fake, "shop", 0, nil)
+	if err != nil {
+		t.Fatalf("listEventsRecursive: %v", err)
+	}
+	if got, want := eventIDs(events), eventIDs(all[:5]); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want the newest five %v", got, want)
+	}
+}
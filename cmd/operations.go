@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// operationList is the structured (--output json|yaml) form of
+// `cfn events --operation`.
+type operationList struct {
+	Operations []stackOperation
+}
+
+func (l operationList) listItems() []any { return toItems(l.Operations) }
+
+// stackOperation is one create, update, delete or import of a stack: the
+// events from a "User Initiated" event up to the next one.
+type stackOperation struct {
+	Operation   string     // e.g. "update"; empty when the start is not among the events
+	StartTime   *time.Time `json:",omitempty"`
+	EndTime     *time.Time `json:",omitempty"` // unset while in progress
+	StackStatus string     // the stack's latest status during the operation
+	Resources   map[string]int
+	StackEvents []types.StackEvent
+}
+
+// parseOperationCount parses --operation: "last" is 1, "all" is -1.
+func parseOperationCount(s string) (int, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "last":
+		return 1, nil
+	case "all":
+		return -1, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --operation %q, expected last, all or a number of operations", s)
+	}
+	return n, nil
+}
+
+// belongsTo reports whether an event is of the given stack, by name or ID.
+func belongsTo(e types.StackEvent, stack string) bool {
+	return getValue(e.StackId) == stack || getValue(e.StackName) == stackNameFromID(stack)
+}
+
+// groupOperations splits the events of stack, and of its nested stacks with
+// --recursive, into operations at the stack's "User Initiated" events.
+// Events and operations are newest first.
+func groupOperations(events []types.StackEvent, stack string) []stackOperation {
+	var ops []stackOperation
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if start := isOperationStart(e) && belongsTo(e, stack); start || len(ops) == 0 {
+			op := stackOperation{}
+			if start {
+				op.Operation = operationKind(e)
+				op.StartTime = e.Timestamp
+			}
+			ops = append(ops, op)
+		}
+		op := &ops[len(ops)-1]
+		op.StackEvents = append(op.StackEvents, e)
+	}
+
+	for i := range ops {
+		finishOperation(&ops[i], stack)
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// finishOperation takes an operation with its events oldest first, reverses
+// them and works out the final status and resource counts. Nested stacks
+// whose own events are included are not counted as resources themselves, so
+// a failure deep down is counted once.
+func finishOperation(op *stackOperation, stack string) {
+	included := make(map[string]bool)
+	for _, e := range op.StackEvents {
+		included[getValue(e.StackId)] = true
+	}
+
+	failed := make(map[string]bool)
+	final := make(map[string]types.StackEvent)
+	var order []string
+	for _, e := range op.StackEvents {
+		status := string(e.ResourceStatus)
+		if id := nestedStackID(e); id != "" && included[id] {
+			continue
+		}
+		if isStackEvent(e) {
+			if belongsTo(e, stack) {
+				op.StackStatus = status
+				op.EndTime = nil
+				if !strings.HasSuffix(status, "_IN_PROGRESS") {
+					op.EndTime = e.Timestamp
+				}
+			}
+			continue
+		}
+		key := getValue(e.StackName) + "/" + getValue(e.LogicalResourceId)
+		if _, ok := final[key]; !ok {
+			order = append(order, key)
+		}
+		final[key] = e
+		if strings.HasSuffix(status, "_FAILED") && !isCancellation(e) {
+			failed[key] = true
+		}
+	}
+
+	op.Resources = make(map[string]int)
+	for _, key := range order {
+		outcome := resourceOutcome(final[key].ResourceStatus)
+		if failed[key] {
+			outcome = "failed"
+		}
+		op.Resources[outcome]++
+	}
+
+	for i, j := 0, len(op.StackEvents)-1; i < j; i, j = i+1, j-1 {
+		op.StackEvents[i], op.StackEvents[j] = op.StackEvents[j], op.StackEvents[i]
+	}
+}
+
+// resourceOutcome summarises a resource's final status in an operation.
+func resourceOutcome(status types.ResourceStatus) string {
+	s := string(status)
+	switch {
+	case s == "DELETE_SKIPPED":
+		return "retained"
+	case strings.HasSuffix(s, "_IN_PROGRESS"):
+		return "in progress"
+	case strings.HasSuffix(s, "_FAILED"):
+		return "failed"
+	case strings.HasSuffix(s, "_COMPLETE"):
+		kind := strings.ToLower(strings.TrimSuffix(s, "_COMPLETE"))
+		if strings.HasSuffix(kind, "e") {
+			return kind + "d"
+		}
+		return kind + "ed"
+	}
+	return strings.ToLower(s)
+}
+
+// outcomeOrder lists resource outcomes in the order they are summarised;
+// others follow alphabetically.
+var outcomeOrder = []string{"created", "updated", "deleted", "imported", "retained", "in progress", "failed"}
+
+func formatResourceCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	rank := func(k string) int {
+		for i, o := range outcomeOrder {
+			if o == k {
+				return i
+			}
+		}
+		return len(outcomeOrder)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ri, rj := rank(keys[i]), rank(keys[j]); ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[k], k))
+	}
+	if len(parts) == 0 {
+		return "no resources changed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// operationSummary is the line printed above an operation's events, e.g.
+// "update started 2024-06-15 08:30:00, took 3m, UPDATE_COMPLETE (2 updated)".
+func operationSummary(op stackOperation) string {
+	kind := op.Operation
+	if kind == "" {
+		kind = "earlier operation"
+	}
+	var b strings.Builder
+	b.WriteString(kind)
+	if op.StartTime != nil {
+		fmt.Fprintf(&b, " started %s", formatTime(op.StartTime))
+		if op.EndTime != nil {
+			fmt.Fprintf(&b, ", took %s", duration.HumanDuration(op.EndTime.Sub(*op.StartTime)))
+		} else {
+			fmt.Fprintf(&b, ", running for %s", duration.HumanDuration(now().Sub(*op.StartTime)))
+		}
+	}
+	if op.StackStatus != "" {
+		fmt.Fprintf(&b, ", %s", op.StackStatus)
+	}
+	fmt.Fprintf(&b, " (%s)", formatResourceCounts(op.Resources))
+	return b.String()
+}
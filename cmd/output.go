@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats accepted by --output. The empty string is the default table view.
+// Template formats take an argument after "=", e.g. jsonpath={.StackName}.
+const (
+	outputTable          = ""
+	outputWide           = "wide"
+	outputJSON           = "json"
+	outputYAML           = "yaml"
+	outputName           = "name"
+	outputGoTemplate     = "go-template"
+	outputGoTemplateFile = "go-template-file"
+	outputJSONPath       = "jsonpath"
+	outputJSONPathFile   = "jsonpath-file"
+	outputCustomColumns  = "custom-columns"
+)
+
+// objectPrinter renders the template based formats; it is built once from
+// --output by SetGlobalFlags so template errors are reported before any API call.
+var objectPrinter printers.ResourcePrinter
+
+func newObjectPrinter(format string) (printers.ResourcePrinter, error) {
+	name, arg, hasArg := strings.Cut(format, "=")
+	switch name {
+	case outputTable, outputWide, outputJSON, outputYAML, outputName:
+		if hasArg {
+			return nil, fmt.Errorf("output format %q does not take an argument", name)
+		}
+		return nil, nil
+	case outputGoTemplate, outputGoTemplateFile, outputJSONPath, outputJSONPathFile, outputCustomColumns:
+		if arg == "" {
+			return nil, fmt.Errorf("output format %q requires an argument, e.g. %s=...", name, name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (expected one of: json, yaml, wide, name, go-template=..., go-template-file=..., jsonpath=..., jsonpath-file=..., custom-columns=...)", name)
+	}
+
+	if name == outputGoTemplateFile || name == outputJSONPathFile {
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file %q: %v", arg, err)
+		}
+		arg = string(data)
+	}
+
+	switch name {
+	case outputGoTemplate, outputGoTemplateFile:
+		p, err := printers.NewGoTemplatePrinter([]byte(arg))
+		if err != nil {
+			return nil, fmt.Errorf("invalid go-template: %v", err)
+		}
+		return p, nil
+	case outputJSONPath, outputJSONPathFile:
+		p, err := printers.NewJSONPathPrinter(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath: %v", err)
+		}
+		return p, nil
+	default:
+		return newCustomColumnsPrinter(arg)
+	}
+}
+
+// isObjectOutput reports whether the selected output format serialises the
+// underlying SDK structs instead of rendering a table.
+func isObjectOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML || objectPrinter != nil
+}
+
+// isWideOutput reports whether tables should include their extra columns.
+func isWideOutput() bool {
+	return outputFormat == outputWide
+}
+
+func printObject(obj any) error {
+	if objectPrinter != nil {
+		if err := objectPrinter.PrintObj(&printableObject{obj: obj}, os.Stdout); err != nil {
+			return fmt.Errorf("error printing output: %w", err)
+		}
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if outputFormat == outputYAML {
+		data, err = yaml.Marshal(obj)
+	} else {
+		data, err = json.MarshalIndent(obj, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("error encoding %s output: %w", outputFormat, err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	return nil
+}
+
+func printNames(names []string) {
+	for _, name := range names {
+		if name != "" {
+			fmt.Println(name)
+		}
+	}
+}
+
+// printableObject adapts an SDK value to runtime.Object so it can be handed
+// to the cli-runtime printers, which only need its JSON encoding.
+type printableObject struct {
+	obj any
+}
+
+func (p *printableObject) GetObjectKind() schema.ObjectKind { return schema.EmptyObjectKind }
+
+func (p *printableObject) DeepCopyObject() runtime.Object { return &printableObject{obj: p.obj} }
+
+func (p *printableObject) MarshalJSON() ([]byte, error) { return json.Marshal(p.obj) }
+
+// itemLister is implemented by list results so custom-columns renders one
+// row per item rather than a single row for the whole document.
+type itemLister interface {
+	listItems() []any
+}
+
+func toItems[T any](items []T) []any {
+	out := make([]any, 0, len(items))
+	for _, item := range items {
+		out = append(out, item)
+	}
+	return out
+}
+
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// customColumnsPrinter renders kubectl style custom-columns, e.g.
+// custom-columns=NAME:.StackName,STATUS:.StackStatus
+type customColumnsPrinter struct {
+	columns []customColumn
+}
+
+func newCustomColumnsPrinter(spec string) (*customColumnsPrinter, error) {
+	p := &customColumnsPrinter{}
+	for _, part := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("invalid custom-columns spec %q, expected HEADER:.path[,HEADER:.path...]", part)
+		}
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + expr + "}"
+		}
+		path := jsonpath.New(header).AllowMissingKeys(true)
+		if err := path.Parse(expr); err != nil {
+			return nil, fmt.Errorf("invalid custom-columns path %q: %v", expr, err)
+		}
+		p.columns = append(p.columns, customColumn{header: header, path: path})
+	}
+	return p, nil
+}
+
+func (p *customColumnsPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	items := []any{obj}
+	if printable, ok := obj.(*printableObject); ok {
+		items = []any{printable.obj}
+		if lister, ok := printable.obj.(itemLister); ok {
+			items = lister.listItems()
+		}
+	}
+
+	headers := make([]string, 0, len(p.columns))
+	for _, c := range p.columns {
+		headers = append(headers, c.header)
+	}
+	table := makeTable(headers)
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		var content interface{}
+		if err := json.Unmarshal(data, &content); err != nil {
+			return err
+		}
+
+		cells := make([]interface{}, 0, len(p.columns))
+		for _, c := range p.columns {
+			results, err := c.path.FindResults(content)
+			if err != nil {
+				return err
+			}
+			var values []string
+			for _, set := range results {
+				for _, v := range set {
+					if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+						continue
+					}
+					values = append(values, fmt.Sprintf("%v", v.Interface()))
+				}
+			}
+			if len(values) == 0 {
+				cells = append(cells, "<none>")
+				continue
+			}
+			cells = append(cells, strings.Join(values, ","))
+		}
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
+	}
+
+	return printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHeaders}).PrintObj(table, w)
+}
@@ -4,40 +4,56 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// outputList is the structured (--output json|yaml) form of a stack's outputs.
+type outputList struct {
+	Outputs []types.Output
+}
+
+func (l outputList) listItems() []any { return toItems(l.Outputs) }
+
 func OutputsCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "outputs <stack-name>",
 		Short: "Show outputs for a CloudFormation stack",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runOutputs(args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOutputs(args[0])
 		},
 	}
 }
 
-func runOutputs(stackName string) {
+func runOutputs(stackName string) error {
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	output, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
-		StackName: &stackName,
-	})
+	stack, err := describeStack(ctx, client, stackName)
 	if err != nil {
-		fatalf("failed to describe stack %q: %v\n", stackName, err)
+		return err
 	}
-	if len(output.Stacks) == 0 {
-		fatalf("stack %q not found\n", stackName)
+
+	outputs := stack.Outputs
+
+	switch {
+	case isObjectOutput():
+		return printObject(outputList{Outputs: append([]types.Output{}, outputs...)})
+	case outputFormat == outputName:
+		for _, o := range outputs {
+			fmt.Println(getValue(o.OutputKey))
+		}
+		return nil
 	}
 
-	outputs := output.Stacks[0].Outputs
 	if len(outputs) == 0 {
 		fmt.Println("No outputs found")
-		return
+		return nil
 	}
 
 	table := makeTable([]string{"KEY", "VALUE", "EXPORT NAME", "DESCRIPTION"})
@@ -51,5 +67,5 @@ func runOutputs(stackName string) {
 			},
 		})
 	}
-	mustPrint(table)
+	return printTable(table)
 }
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operators accepted by --property. Two character operators are listed first
+// so that "a!=b" is not read as "a!" = "b".
+const (
+	opEquals    = "="
+	opNotEquals = "!="
+	opMatches   = "~="
+	opGreater   = ">"
+	opLess      = "<"
+	opGreaterEq = ">="
+	opLessEq    = "<="
+	opExists    = "exists"
+	opNotExists = "!exists"
+)
+
+var comparisonOperators = []string{opNotEquals, opMatches, opGreaterEq, opLessEq, opEquals, opGreater, opLess}
+
+// propertyFilter is a parsed --property expression such as
+// "Tags[*].Key=Owner", "Runtime~=python3\.[0-8]" or "KmsMasterKeyId !exists".
+type propertyFilter struct {
+	expr  string
+	path  []pathStep
+	op    string
+	value string
+	re    *regexp.Regexp
+	num   float64
+}
+
+// pathStep is one step of a property path: a map key, a list index or a
+// [*] wildcard over every list element.
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (f propertyFilter) String() string { return f.expr }
+
+// pathString is the path part of the expression, used to label matched values.
+func (f propertyFilter) pathString() string {
+	var b strings.Builder
+	for _, s := range f.path {
+		switch {
+		case s.wildcard:
+			b.WriteString("[*]")
+		case s.isIndex:
+			fmt.Fprintf(&b, "[%d]", s.index)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s.key)
+		}
+	}
+	return b.String()
+}
+
+func parsePropertyFilter(expr string, ignoreCase bool) (propertyFilter, error) {
+	f := propertyFilter{expr: expr}
+
+	var pathExpr string
+	if fields := strings.Fields(expr); len(fields) == 2 && (fields[1] == opExists || fields[1] == opNotExists) {
+		pathExpr, f.op = fields[0], fields[1]
+	} else {
+		idx, op := -1, ""
+		for i := 0; i < len(expr) && idx < 0; i++ {
+			for _, candidate := range comparisonOperators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					idx, op = i, candidate
+					break
+				}
+			}
+		}
+		if idx < 0 {
+			return f, fmt.Errorf("invalid property filter %q, expected PATH=VALUE, PATH!=VALUE, PATH~=REGEX, PATH>N, PATH<N, \"PATH exists\" or \"PATH !exists\"", expr)
+		}
+		pathExpr, f.op, f.value = expr[:idx], op, expr[idx+len(op):]
+	}
+
+	path, err := parsePropertyPath(strings.TrimSpace(pathExpr))
+	if err != nil {
+		return f, fmt.Errorf("invalid property filter %q: %w", expr, err)
+	}
+	f.path = path
+
+	switch f.op {
+	case opMatches:
+		pattern := f.value
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		if f.re, err = regexp.Compile(pattern); err != nil {
+			return f, fmt.Errorf("invalid regular expression in %q: %w", expr, err)
+		}
+	case opGreater, opLess, opGreaterEq, opLessEq:
+		if f.num, err = strconv.ParseFloat(strings.TrimSpace(f.value), 64); err != nil {
+			return f, fmt.Errorf("invalid number in %q: %s", expr, f.value)
+		}
+	}
+	return f, nil
+}
+
+// parsePropertyPath splits "Policies[0].PolicyDocument.Statement[*].Effect"
+// into steps.
+func parsePropertyPath(path string) ([]pathStep, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty property path")
+	}
+
+	var steps []pathStep
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		if i := strings.IndexByte(segment, '['); i >= 0 {
+			key = segment[:i]
+		}
+		if key == "" && len(steps) == 0 {
+			return nil, fmt.Errorf("property path %q must start with a property name", path)
+		}
+		if key != "" {
+			steps = append(steps, pathStep{key: key})
+		}
+
+		rest := segment[len(key):]
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid index in property path %q", path)
+			}
+			inner := rest[1:end]
+			if inner == "*" {
+				steps = append(steps, pathStep{wildcard: true})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index [%s] in property path %q", inner, path)
+				}
+				steps = append(steps, pathStep{index: n, isIndex: true})
+			}
+			rest = rest[end+1:]
+		}
+	}
+	return steps, nil
+}
+
+// resolvePath returns every value reached by the path; wildcards can yield
+// several.
+func resolvePath(value interface{}, steps []pathStep, ignoreCase bool) []interface{} {
+	if len(steps) == 0 {
+		return []interface{}{value}
+	}
+	step, rest := steps[0], steps[1:]
+
+	switch {
+	case step.wildcard:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		var out []interface{}
+		for _, item := range list {
+			out = append(out, resolvePath(item, rest, ignoreCase)...)
+		}
+		return out
+	case step.isIndex:
+		list, ok := value.([]interface{})
+		if !ok || step.index >= len(list) {
+			return nil
+		}
+		return resolvePath(list[step.index], rest, ignoreCase)
+	default:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if v, exists := m[step.key]; exists {
+			return resolvePath(v, rest, ignoreCase)
+		}
+		if ignoreCase {
+			for k, v := range m {
+				if strings.EqualFold(k, step.key) {
+					return resolvePath(v, rest, ignoreCase)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// comparableStrings returns the strings a value is compared as. Scalars are
+// formatted as-is; Ref, Fn::Sub, Fn::GetAtt and Fn::ImportValue compare as
+// their argument, so "BucketName=${AWS::StackName}-logs" matches a Fn::Sub.
+func comparableStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		if len(v) != 1 {
+			return nil
+		}
+		for fn, arg := range v {
+			switch fn {
+			case "Ref", "Fn::ImportValue":
+				return comparableStrings(arg)
+			case "Fn::Sub":
+				if list, ok := arg.([]interface{}); ok && len(list) > 0 {
+					return comparableStrings(list[0])
+				}
+				return comparableStrings(arg)
+			case "Fn::GetAtt":
+				if list, ok := arg.([]interface{}); ok {
+					parts := make([]string, 0, len(list))
+					for _, p := range list {
+						parts = append(parts, fmt.Sprintf("%v", p))
+					}
+					return []string{strings.Join(parts, ".")}
+				}
+				return comparableStrings(arg)
+			}
+		}
+		return nil
+	case []interface{}:
+		return nil
+	case float64:
+		// JSON numbers; %v would print 1209600 as 1.2096e+06
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
+
+// match reports whether the properties satisfy the filter and returns the
+// values that did.
+func (f propertyFilter) match(properties map[string]interface{}, ignoreCase bool) (bool, []interface{}) {
+	values := resolvePath(properties, f.path, ignoreCase)
+
+	switch f.op {
+	case opExists:
+		return len(values) > 0, values
+	case opNotExists:
+		return len(values) == 0, nil
+	case opNotEquals:
+		// Present, and no value equal to the operand
+		if len(values) == 0 {
+			return false, nil
+		}
+		for _, v := range values {
+			for _, s := range comparableStrings(v) {
+				if equalsWithCase(s, f.value, ignoreCase) {
+					return false, nil
+				}
+			}
+		}
+		return true, values
+	}
+
+	var matched []interface{}
+	for _, v := range values {
+		for _, s := range comparableStrings(v) {
+			if f.compare(s, ignoreCase) {
+				matched = append(matched, v)
+				break
+			}
+		}
+	}
+	return len(matched) > 0, matched
+}
+
+func (f propertyFilter) compare(s string, ignoreCase bool) bool {
+	switch f.op {
+	case opEquals:
+		return equalsWithCase(s, f.value, ignoreCase)
+	case opMatches:
+		return f.re.MatchString(s)
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return false
+	}
+	switch f.op {
+	case opGreater:
+		return n > f.num
+	case opLess:
+		return n < f.num
+	case opGreaterEq:
+		return n >= f.num
+	case opLessEq:
+		return n <= f.num
+	}
+	return false
+}
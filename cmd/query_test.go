@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParsePropertyFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		path    string
+		op      string
+		value   string
+		wantErr bool
+	}{
+		{expr: "BucketName=foo", path: "BucketName", op: opEquals, value: "foo"},
+		{expr: "Runtime!=python3.12", path: "Runtime", op: opNotEquals, value: "python3.12"},
+		{expr: "Runtime~=^python3\\.[0-8]$", path: "Runtime", op: opMatches, value: "^python3\\.[0-8]$"},
+		{expr: "MemorySize>=512", path: "MemorySize", op: opGreaterEq, value: "512"},
+		{expr: "MemorySize<128", path: "MemorySize", op: opLess, value: "128"},
+		{expr: "Tags[*].Key=Owner", path: "Tags[*].Key", op: opEquals, value: "Owner"},
+		{expr: "Policies[0].PolicyName=a=b", path: "Policies[0].PolicyName", op: opEquals, value: "a=b"},
+		{expr: "BucketEncryption exists", path: "BucketEncryption", op: opExists},
+		{expr: "BucketEncryption !exists", path: "BucketEncryption", op: opNotExists},
+		{expr: "BucketName", wantErr: true},
+		{expr: "=foo", wantErr: true},
+		{expr: "Tags[x].Key=Owner", wantErr: true},
+		{expr: "Tags[0=Owner", wantErr: true},
+		{expr: "Runtime~=(", wantErr: true},
+		{expr: "MemorySize>lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parsePropertyFilter(tt.expr, false)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", f)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePropertyFilter: %v", err)
+			}
+			if f.pathString() != tt.path || f.op != tt.op || f.value != tt.value {
+				t.Errorf("got path %q op %q value %q, want %q %q %q", f.pathString(), f.op, f.value, tt.path, tt.op, tt.value)
+			}
+		})
+	}
+}
+
+func TestPropertyFilterMatch(t *testing.T) {
+	properties := map[string]interface{}{
+		"BucketName": map[string]interface{}{"Fn::Sub": "${AWS::StackName}-logs"},
+		"MemorySize": 512,
+		"Timeout":    "30",
+		"Runtime":    "python3.8",
+		"Role":       map[string]interface{}{"Fn::GetAtt": []interface{}{"HandlerRole", "Arn"}},
+		"VpcId":      map[string]interface{}{"Ref": "Vpc"},
+		"Tags": []interface{}{
+			map[string]interface{}{"Key": "Name", "Value": "logs"},
+			map[string]interface{}{"Key": "Owner", "Value": "platform"},
+		},
+		"Policies": []interface{}{
+			map[string]interface{}{"PolicyName": "read"},
+		},
+	}
+
+	tests := []struct {
+		expr       string
+		ignoreCase bool
+		want       bool
+	}{
+		{expr: "Runtime=python3.8", want: true},
+		{expr: "MemorySize=512", want: true},
+		{expr: "runtime=PYTHON3.8", want: false},
+		{expr: "runtime=PYTHON3.8", ignoreCase: true, want: true},
+		{expr: "Runtime!=python3.12", want: true},
+		{expr: "Runtime!=python3.8", want: false},
+		{expr: "Missing!=x", want: false},
+		{expr: `Runtime~=^python3\.[0-8]$`, want: true},
+		{expr: "MemorySize>256", want: true},
+		{expr: "MemorySize<256", want: false},
+		{expr: "Timeout<=30", want: true},
+		{expr: "Runtime>1", want: false},
+		{expr: "Tags exists", want: true},
+		{expr: "Tags[5] exists", want: false},
+		{expr: "BucketEncryption !exists", want: true},
+		{expr: "Tags !exists", want: false},
+		{expr: "Tags[*].Key=Owner", want: true},
+		{expr: "Tags[*].Key=Team", want: false},
+		{expr: "Tags[*].Key!=Team", want: true},
+		{expr: "Tags[*].Key!=Owner", want: false},
+		{expr: "Tags[0].Key=Owner", want: false},
+		{expr: "Policies[0].PolicyName=read", want: true},
+		{expr: "BucketName=${AWS::StackName}-logs", want: true},
+		{expr: "BucketName~=-logs$", want: true},
+		{expr: "Role=HandlerRole.Arn", want: true},
+		{expr: "VpcId=Vpc", want: true},
+		{expr: "Tags=logs", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parsePropertyFilter(tt.expr, tt.ignoreCase)
+			if err != nil {
+				t.Fatalf("parsePropertyFilter: %v", err)
+			}
+			if got, _ := f.match(properties, tt.ignoreCase); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropertyFilterJSONNumbers(t *testing.T) {
+	// JSON templates decode numbers as float64
+	var properties map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"MessageRetentionPeriod": 1209600, "DelaySeconds": 0.5}`), &properties); err != nil {
+		t.Fatal(err)
+	}
+
+	for expr, want := range map[string]bool{
+		"MessageRetentionPeriod=1209600":  true,
+		"MessageRetentionPeriod!=1209600": false,
+		"MessageRetentionPeriod!=345600":  true,
+		"MessageRetentionPeriod>=1209600": true,
+		"DelaySeconds=0.5":                true,
+	} {
+		f, err := parsePropertyFilter(expr, false)
+		if err != nil {
+			t.Fatalf("parsePropertyFilter(%q): %v", expr, err)
+		}
+		if got, _ := f.match(properties, false); got != want {
+			t.Errorf("%s: got %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestCheckPropertiesMatchedValues(t *testing.T) {
+	properties := map[string]interface{}{
+		"Tags": []interface{}{
+			map[string]interface{}{"Key": "Name", "Value": "a"},
+			map[string]interface{}{"Key": "Owner", "Value": "b"},
+		},
+		"MemorySize": 512,
+	}
+
+	var filters []propertyFilter
+	for _, expr := range []string{"Tags[*].Value~=.", "MemorySize>100", "Encryption !exists"} {
+		f, err := parsePropertyFilter(expr, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filters = append(filters, f)
+	}
+
+	ok, matched := checkProperties(properties, filters, false)
+	if !ok {
+		t.Fatal("expected properties to match")
+	}
+	want := map[string]interface{}{
+		"Tags[*].Value": []interface{}{"a", "b"},
+		"MemorySize":    512,
+	}
+	if !reflect.DeepEqual(matched, want) {
+		t.Errorf("got %v, want %v", matched, want)
+	}
+}
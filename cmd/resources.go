@@ -4,60 +4,78 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// resourceList is the structured (--output json|yaml) form of a stack's resources.
+type resourceList struct {
+	StackResourceSummaries []types.StackResourceSummary
+}
+
+func (l resourceList) listItems() []any { return toItems(l.StackResourceSummaries) }
+
 func ResourcesCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "resources <stack-name>",
 		Short: "List physical resources in a CloudFormation stack",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runResources(args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResources(args[0])
 		},
 	}
 }
 
-func runResources(stackName string) {
+func runResources(stackName string) error {
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	var all []types.StackResourceSummary
-	paginator := cloudformation.NewListStackResourcesPaginator(client, &cloudformation.ListStackResourcesInput{
-		StackName: &stackName,
-	})
+	all, err := listStackResources(ctx, client, stackName)
+	if err != nil {
+		return fmt.Errorf("failed to list resources for stack %q: %w", stackName, err)
+	}
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			fatalf("failed to list resources for stack %q: %v\n", stackName, err)
+	switch {
+	case isObjectOutput():
+		return printObject(resourceList{StackResourceSummaries: append([]types.StackResourceSummary{}, all...)})
+	case outputFormat == outputName:
+		for _, r := range all {
+			fmt.Println(getValue(r.LogicalResourceId))
 		}
-		all = append(all, output.StackResourceSummaries...)
+		return nil
 	}
 
 	if len(all) == 0 {
 		fmt.Println("No resources found")
-		return
+		return nil
 	}
 
-	table := makeTable([]string{"LOGICAL ID", "PHYSICAL ID", "TYPE", "STATUS", "DRIFT"})
+	wide := isWideOutput()
+	columns := []string{"LOGICAL ID", "PHYSICAL ID", "TYPE", "STATUS", "DRIFT"}
+	if wide {
+		columns = append(columns, "LAST UPDATED", "REASON")
+	}
+	table := makeTable(columns)
 	for _, r := range all {
 		drift := ""
 		if r.DriftInformation != nil {
 			drift = string(r.DriftInformation.StackResourceDriftStatus)
 		}
-		table.Rows = append(table.Rows, v1.TableRow{
-			Cells: []interface{}{
-				getValue(r.LogicalResourceId),
-				getValue(r.PhysicalResourceId),
-				getValue(r.ResourceType),
-				string(r.ResourceStatus),
-				drift,
-			},
-		})
+		cells := []interface{}{
+			getValue(r.LogicalResourceId),
+			getValue(r.PhysicalResourceId),
+			getValue(r.ResourceType),
+			string(r.ResourceStatus),
+			drift,
+		}
+		if wide {
+			cells = append(cells, formatTime(r.LastUpdatedTimestamp), getValue(r.ResourceStatusReason))
+		}
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
 	}
-	mustPrint(table)
+	return printTable(table)
 }
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// defaultConcurrency is the number of templates fetched in parallel during a
+// resource search. The adaptive retryer slows workers down when throttled.
+const defaultConcurrency = 8
+
+// searchResult is the outcome of searching one stack's template.
+type searchResult struct {
+	matches []resourceMatch
+	err     error
+}
+
+// searchStacks runs search for every stack on a pool of at most concurrency
+// workers and returns the results in input order, regardless of the order in
+// which they complete. onDone, if set, is called after each stack with the
+// number of stacks finished so far.
+func searchStacks(ctx context.Context, stacks []stackRef, concurrency int, search func(context.Context, stackRef) ([]resourceMatch, error), onDone func(completed int)) []searchResult {
+	results := make([]searchResult, len(stacks))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	completed := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(stacks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				matches, err := search(ctx, stacks[i])
+				results[i] = searchResult{matches: matches, err: err}
+
+				mu.Lock()
+				completed++
+				if onDone != nil {
+					onDone(completed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range stacks {
+		if ctx.Err() != nil {
+			results[i] = searchResult{err: ctx.Err()}
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Reasons a stack could not be searched.
+const (
+	skipAccessDenied  = "access denied"
+	skipThrottled     = "throttled"
+	skipNotFound      = "not found"
+	skipParseError    = "parse error"
+	skipEmptyTemplate = "empty template"
+	skipError         = "error"
+)
+
+// skippedStack is a stack whose template could not be searched. It is part of
+// the structured output so audits can tell a miss from a gap in coverage.
+type skippedStack struct {
+	StackName string
+	StackId   string `json:",omitempty"`
+	Reason    string
+	Error     string
+
+	err error
+}
+
+func newSkippedStack(stack types.StackSummary, err error) skippedStack {
+	return skippedStack{
+		StackName: getValue(stack.StackName),
+		StackId:   getValue(stack.StackId),
+		Reason:    skipReason(err),
+		Error:     err.Error(),
+		err:       err,
+	}
+}
+
+func skipReason(err error) string {
+	switch {
+	case errors.Is(err, errEmptyTemplate):
+		return skipEmptyTemplate
+	case errors.Is(err, errTemplateParse):
+		return skipParseError
+	}
+	switch ExitCode(err) {
+	case exitAuthError:
+		return skipAccessDenied
+	case exitThrottled:
+		return skipThrottled
+	case exitNotFound:
+		return skipNotFound
+	}
+	return skipError
+}
+
+// reportSkipped lists the stacks a search could not cover on stderr.
+func reportSkipped(skipped []skippedStack, total int, location bool) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "warning: %d of %d stacks could not be searched:\n", len(skipped), total)
+	for _, s := range skipped {
+		name := s.StackName
+		if location {
+			account, region := stackLocation(s.StackId)
+			name = fmt.Sprintf("%s (%s/%s)", name, account, region)
+		}
+		detail := s.Reason
+		if s.Error != s.Reason {
+			detail += ": " + s.Error
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", name, detail)
+	}
+}
+
+// errIncompleteSearch is returned by --strict when stacks were skipped. The
+// exit code follows the first failure, e.g. 6 when access was denied.
+func errIncompleteSearch(skipped []skippedStack, total int) error {
+	code := ExitCode(skipped[0].err)
+	if code == exitOK {
+		code = exitError
+	}
+	return withExitCode(code, fmt.Errorf("%d of %d stacks could not be searched", len(skipped), total))
+}
+
+// searchProgress shows a live "done/total" counter after msg on stderr. On a
+// terminal the line is redrawn in place and cleared when finished; otherwise
+// msg is printed once so logs are not filled with control characters.
+type searchProgress struct {
+	msg   string
+	total int
+	live  bool
+}
+
+func newSearchProgress(msg string, total int) *searchProgress {
+	p := &searchProgress{msg: msg, total: total, live: isTerminal(os.Stderr)}
+	if p.live {
+		p.update(0)
+	} else {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+	return p
+}
+
+func (p *searchProgress) update(completed int) {
+	if p.live {
+		fmt.Fprintf(os.Stderr, "\r\033[2K%s %d/%d", p.msg, completed, p.total)
+	}
+}
+
+func (p *searchProgress) finish() {
+	if p.live {
+		fmt.Fprint(os.Stderr, "\r\033[2K")
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func refsNamed(names ...string) []stackRef {
+	refs := make([]stackRef, 0, len(names))
+	for _, n := range names {
+		refs = append(refs, stackRef{summary: types.StackSummary{StackName: aws.String(n)}})
+	}
+	return refs
+}
+
+func TestSearchStacksOrderAndConcurrency(t *testing.T) {
+	stacks := refsNamed("a", "b", "c", "d", "e", "f", "g", "h")
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	search := func(_ context.Context, ref stackRef) ([]resourceMatch, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		// Earlier stacks finish last so completion order is reversed
+		name := getValue(ref.summary.StackName)
+		time.Sleep(time.Duration('h'-name[0]) * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if name == "c" {
+			return nil, errors.New("access denied")
+		}
+		if name[0]%2 == 0 {
+			return []resourceMatch{{StackName: name}}, nil
+		}
+		return nil, nil
+	}
+
+	var progress []int
+	results := searchStacks(context.Background(), stacks, 3, search, func(n int) { progress = append(progress, n) })
+
+	if peak > 3 {
+		t.Errorf("ran %d searches at once, want at most 3", peak)
+	}
+	for i, r := range results {
+		name := getValue(stacks[i].summary.StackName)
+		if name == "c" {
+			if r.err == nil {
+				t.Errorf("expected error for %s", name)
+			}
+			continue
+		}
+		if want := name[0]%2 == 0; (len(r.matches) > 0) != want || r.err != nil {
+			t.Errorf("%s: got %+v, want matched=%v", name, r, want)
+		}
+	}
+	if len(progress) != len(stacks) || progress[len(progress)-1] != len(stacks) {
+		t.Errorf("unexpected progress updates: %v", progress)
+	}
+}
+
+func TestSearchStacksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := searchStacks(ctx, refsNamed("a", "b"), 2, func(_ context.Context, ref stackRef) ([]resourceMatch, error) {
+		return []resourceMatch{{StackName: getValue(ref.summary.StackName)}}, nil
+	}, nil)
+	for _, r := range results {
+		if len(r.matches) > 0 || !errors.Is(r.err, context.Canceled) {
+			t.Errorf("got %+v, want a cancellation error", r)
+		}
+	}
+}
+
+func TestRunListResourceSearchConcurrent(t *testing.T) {
+	client := newFakeClient(t)
+	setOutput(t, "name")
+	cmd := ListCmd()
+	resourceType = "AWS::SQS::Queue"
+	concurrency = 4
+	t.Cleanup(func() {
+		resourceType = ""
+		concurrency = defaultConcurrency
+		namesOnly = false
+	})
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, nil) })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	// Same order as the stack listing
+	if want := "prod-app-api\nprod-app-worker\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if client.calls["GetTemplate"] != 5 {
+		t.Errorf("expected 5 GetTemplate calls, got %d", client.calls["GetTemplate"])
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// Keys accepted by --sort-by. Without one, stacks keep the API order.
+const (
+	sortByName    = "name"
+	sortByCreated = "created"
+	sortByUpdated = "updated"
+	sortByStatus  = "status"
+)
+
+var sortKeys = []string{sortByName, sortByCreated, sortByUpdated, sortByStatus}
+
+func validateSortKey(key string) error {
+	if key == "" {
+		return nil
+	}
+	for _, k := range sortKeys {
+		if key == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --sort-by %q (expected one of: %s)", key, strings.Join(sortKeys, ", "))
+}
+
+// stackActivityTime is when a stack last changed: its last update, or its
+// creation if it was never updated.
+func stackActivityTime(s types.StackSummary) *time.Time {
+	if s.LastUpdatedTime != nil {
+		return s.LastUpdatedTime
+	}
+	return s.CreationTime
+}
+
+// sortStackRefs orders stacks by key, ties broken by name. Stacks without the
+// sorted timestamp come first.
+func sortStackRefs(refs []stackRef, key string, reverse bool) {
+	if key == "" {
+		if reverse {
+			for i, j := 0, len(refs)-1; i < j; i, j = i+1, j-1 {
+				refs[i], refs[j] = refs[j], refs[i]
+			}
+		}
+		return
+	}
+
+	compare := func(a, b types.StackSummary) int {
+		switch key {
+		case sortByCreated:
+			return compareTimes(a.CreationTime, b.CreationTime)
+		case sortByUpdated:
+			return compareTimes(stackActivityTime(a), stackActivityTime(b))
+		case sortByStatus:
+			return strings.Compare(string(a.StackStatus), string(b.StackStatus))
+		}
+		return 0
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		a, b := refs[i].summary, refs[j].summary
+		c := compare(a, b)
+		if c == 0 {
+			c = strings.Compare(getValue(a.StackName), getValue(b.StackName))
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+// filterByAge keeps the stacks whose last change is more than olderThan ago
+// and less than newerThan ago; a zero duration disables that bound.
+func filterByAge(refs []stackRef, olderThan, newerThan time.Duration) []stackRef {
+	if olderThan == 0 && newerThan == 0 {
+		return refs
+	}
+	current := now()
+	var kept []stackRef
+	for _, r := range refs {
+		t := stackActivityTime(r.summary)
+		if t == nil {
+			continue
+		}
+		age := current.Sub(*t)
+		if olderThan > 0 && age < olderThan {
+			continue
+		}
+		if newerThan > 0 && age > newerThan {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setNow pins the clock used for ages.
+func setNow(t *testing.T, at string) {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return ts }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "90d", want: 90 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "720h", want: 720 * time.Hour},
+		{in: "1w2d30m", want: 9*24*time.Hour + 30*time.Minute},
+		{in: "", want: 0},
+		{in: "d", wantErr: true},
+		{in: "90days", wantErr: true},
+		{in: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAge(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSortAndFilterByAge(t *testing.T) {
+	setNow(t, "2024-09-01T00:00:00Z")
+
+	tests := []struct {
+		name      string
+		sortBy    string
+		reverse   bool
+		olderThan time.Duration
+		newerThan time.Duration
+		want      []string
+	}{
+		{
+			name: "API order",
+			want: []string{"prod-network-vpc", "prod-app-api", "dev-app-api", "prod-app-worker", "legacy-app"},
+		},
+		{
+			name:    "reverse API order",
+			reverse: true,
+			want:    []string{"legacy-app", "prod-app-worker", "dev-app-api", "prod-app-api", "prod-network-vpc"},
+		},
+		{
+			name:   "name",
+			sortBy: sortByName,
+			want:   []string{"dev-app-api", "legacy-app", "prod-app-api", "prod-app-worker", "prod-network-vpc"},
+		},
+		{
+			name:   "created",
+			sortBy: sortByCreated,
+			want:   []string{"legacy-app", "prod-network-vpc", "prod-app-api", "prod-app-worker", "dev-app-api"},
+		},
+		{
+			name:    "updated, most recent first",
+			sortBy:  sortByUpdated,
+			reverse: true,
+			want:    []string{"prod-app-worker", "dev-app-api", "prod-app-api", "prod-network-vpc", "legacy-app"},
+		},
+		{
+			name:   "status, ties by name",
+			sortBy: sortByStatus,
+			want:   []string{"prod-network-vpc", "legacy-app", "prod-app-api", "prod-app-worker", "dev-app-api"},
+		},
+		{
+			name:      "older than uses the last update",
+			sortBy:    sortByName,
+			olderThan: 70 * 24 * time.Hour,
+			want:      []string{"legacy-app", "prod-app-api", "prod-network-vpc"},
+		},
+		{
+			name:      "newer than",
+			sortBy:    sortByName,
+			newerThan: 70 * 24 * time.Hour,
+			want:      []string{"dev-app-api", "prod-app-worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeClient(t)
+			refs, err := listStacksAcross(context.Background(), []target{defaultTarget()}, nil, nil, "", "", false)
+			if err != nil {
+				t.Fatalf("listStacksAcross: %v", err)
+			}
+			refs = filterByAge(refs, tt.olderThan, tt.newerThan)
+			sortStackRefs(refs, tt.sortBy, tt.reverse)
+			if got := stackNames(stackSummaries(refs)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListAgeColumn(t *testing.T) {
+	setNow(t, "2024-02-04T13:00:00Z")
+	newFakeClient(t)
+	setOutput(t, "")
+	cmd := ListCmd()
+	nameFilters = nil
+	t.Cleanup(func() { nameFilters = nil })
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, []string{"prod-app-api"}) })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	// Created 2024-02-01T12:00:00Z, updated 2024-06-15
+	for _, want := range []string{"LAST UPDATED", "AGE", "2024-06-15 08:30:00", "3d1h"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestListInvalidSortAndAge(t *testing.T) {
+	newFakeClient(t)
+	setOutput(t, "")
+	cmd := ListCmd()
+	t.Cleanup(func() {
+		sortBy = ""
+		olderThan = ""
+	})
+
+	sortBy = "size"
+	if err := runList(cmd, nil); err == nil || !strings.Contains(err.Error(), "--sort-by") {
+		t.Errorf("expected an invalid --sort-by error, got %v", err)
+	}
+	sortBy = ""
+	olderThan = "soon"
+	if err := runList(cmd, nil); err == nil || !strings.Contains(err.Error(), "--older-than") {
+		t.Errorf("expected an invalid --older-than error, got %v", err)
+	}
+}
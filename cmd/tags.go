@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// tagFilter is a parsed --tag expression: "Key=Value" requires the tag to
+// have that value, "Key" only requires the tag to be set.
+type tagFilter struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+func parseTagFilters(exprs []string) ([]tagFilter, error) {
+	filters := make([]tagFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		key, value, hasValue := strings.Cut(expr, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid tag filter %q, expected key=value or key", expr)
+		}
+		filters = append(filters, tagFilter{key: key, value: value, hasValue: hasValue})
+	}
+	return filters, nil
+}
+
+func (f tagFilter) match(tags map[string]string, ignoreCase bool) bool {
+	for k, v := range tags {
+		if equalsWithCase(k, f.key, ignoreCase) && (!f.hasValue || equalsWithCase(v, f.value, ignoreCase)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByTags keeps the stacks whose tags satisfy every filter.
+func filterByTags(refs []stackRef, filters []tagFilter, ignoreCase bool) []stackRef {
+	if len(filters) == 0 {
+		return refs
+	}
+	var kept []stackRef
+	for _, r := range refs {
+		matched := true
+		for _, f := range filters {
+			if !f.match(r.tags, ignoreCase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// stackTagsByID indexes the tags of the given stacks by stack ID, as used by
+// printStacks for --show-tag columns.
+func stackTagsByID(refs []stackRef) map[string]map[string]string {
+	tags := make(map[string]map[string]string, len(refs))
+	for _, r := range refs {
+		tags[getValue(r.summary.StackId)] = r.tags
+	}
+	return tags
+}
+
+// attachStackTags fills in the tags of every stack, which ListStacks does not
+// return. Accounts and regions with more stacks than concurrency are described
+// in one paginated batch; the remaining stacks, including deleted ones the
+// batch doesn't cover, are described one by one by ID on at most concurrency
+// workers. Stacks that can't be described are left without tags and listed on
+// stderr; with strict that is an error.
+func attachStackTags(ctx context.Context, refs []stackRef, concurrency int, strict bool) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	byClient := make(map[cloudFormationAPI][]int)
+	for i, r := range refs {
+		byClient[r.client] = append(byClient[r.client], i)
+	}
+	var clients []cloudFormationAPI
+	for _, r := range refs {
+		if len(byClient[r.client]) > concurrency && !containsClient(clients, r.client) {
+			clients = append(clients, r.client)
+		}
+	}
+
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for c, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[c] = describeTagsBatch(ctx, client, refs, byClient[client])
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to describe stacks: %w", err)
+		}
+	}
+
+	var missing []int
+	for i, r := range refs {
+		if r.tags == nil {
+			missing = append(missing, i)
+		}
+	}
+	failed := make([]error, len(refs))
+	sem := make(chan struct{}, concurrency)
+	for _, i := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			stack, err := describeStack(ctx, refs[i].client, stackNameOrID(refs[i].summary))
+			if err != nil {
+				refs[i].tags = map[string]string{}
+				failed[i] = err
+				return
+			}
+			refs[i].tags = tagMap(stack.Tags)
+		}()
+	}
+	wg.Wait()
+	return reportUntagged(refs, failed, strict)
+}
+
+// reportUntagged lists on stderr the stacks whose tags could not be read,
+// which no --tag filter can match. With strict it fails with the exit code of
+// the first failure, e.g. 6 when access was denied.
+func reportUntagged(refs []stackRef, failed []error, strict bool) error {
+	var first error
+	n := 0
+	for _, err := range failed {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: the tags of %d of %d stacks could not be read:\n", n, len(refs))
+	for i, err := range failed {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  %s: %s: %v\n", getValue(refs[i].summary.StackName), skipReason(err), err)
+		}
+	}
+	if !strict {
+		return nil
+	}
+	code := ExitCode(first)
+	if code == exitOK {
+		code = exitError
+	}
+	return withExitCode(code, fmt.Errorf("the tags of %d of %d stacks could not be read", n, len(refs)))
+}
+
+func containsClient(clients []cloudFormationAPI, client cloudFormationAPI) bool {
+	for _, c := range clients {
+		if c == client {
+			return true
+		}
+	}
+	return false
+}
+
+// describeTagsBatch describes every live stack of one client and records the
+// tags of the stacks at indexes.
+func describeTagsBatch(ctx context.Context, client cloudFormationAPI, refs []stackRef, indexes []int) error {
+	byID := make(map[string]int, len(indexes))
+	for _, i := range indexes {
+		byID[getValue(refs[i].summary.StackId)] = i
+	}
+
+	paginator := cloudformation.NewDescribeStacksPaginator(client, &cloudformation.DescribeStacksInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, stack := range output.Stacks {
+			if i, ok := byID[getValue(stack.StackId)]; ok {
+				refs[i].tags = tagMap(stack.Tags)
+			}
+		}
+	}
+	return nil
+}
+
+// tagMap converts stack tags to a map. It is never nil, so a described stack
+// without tags can be told apart from one that wasn't described.
+func tagMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[getValue(t.Key)] = getValue(t.Value)
+	}
+	return m
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+// runTagList runs `cfn list` with the given tag flags.
+func runTagList(t *testing.T, format string, all bool, tags, show []string) (string, error) {
+	t.Helper()
+	setOutput(t, format)
+	cmd := ListCmd()
+	filterAll = all
+	tagFilterExprs = tags
+	showTags = show
+	t.Cleanup(func() {
+		filterAll = false
+		tagFilterExprs = nil
+		showTags = nil
+		namesOnly = false
+	})
+
+	var err error
+	out := captureStdout(t, func() { err = runList(cmd, nil) })
+	return out, err
+}
+
+func TestListTagFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		all  bool
+		tags []string
+		want string
+	}{
+		{name: "key and value", tags: []string{"Owner=team-api"}, want: "prod-app-api\ndev-app-api\n"},
+		{name: "all filters must match", tags: []string{"Owner=team-api", "Environment=prod"}, want: "prod-app-api\n"},
+		{name: "key only", tags: []string{"Environment"}, want: "prod-network-vpc\nprod-app-api\ndev-app-api\n"},
+		{name: "deleted stacks are described by ID", all: true, tags: []string{"Owner=team-legacy"}, want: "legacy-app\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeClient(t)
+			out, err := runTagList(t, "name", tt.all, tt.tags, nil)
+			if err != nil {
+				t.Fatalf("runList: %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestListTagFiltersNoMatch(t *testing.T) {
+	newFakeClient(t)
+	if _, err := runTagList(t, "name", false, []string{"Owner=nobody"}, nil); ExitCode(err) != exitNoMatches {
+		t.Errorf("expected exit code %d, got %v", exitNoMatches, err)
+	}
+	if _, err := runTagList(t, "name", false, []string{"=x"}, nil); err == nil {
+		t.Error("expected an invalid tag filter to fail")
+	}
+}
+
+func TestAttachStackTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int
+	}{
+		// 4 live stacks in pages of 2, plus the deleted stack on its own
+		{name: "many stacks are described in batches", concurrency: 2, want: 3},
+		{name: "few stacks are described one by one", concurrency: 8, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(t)
+			refs, err := listStacksAcross(context.Background(), []target{defaultTarget()}, nil, nil, "", "", false)
+			if err != nil {
+				t.Fatalf("listStacksAcross: %v", err)
+			}
+			if err := attachStackTags(context.Background(), refs, tt.concurrency, false); err != nil {
+				t.Fatalf("attachStackTags: %v", err)
+			}
+			if got := client.calls["DescribeStacks"]; got != tt.want {
+				t.Errorf("expected %d DescribeStacks calls, got %d", tt.want, got)
+			}
+			for _, r := range refs {
+				if r.tags == nil {
+					t.Errorf("%s was not described", getValue(r.summary.StackName))
+				}
+			}
+			if got := refs[len(refs)-1].tags["Owner"]; got != "team-legacy" {
+				t.Errorf("deleted stack Owner = %q, want team-legacy", got)
+			}
+		})
+	}
+}
+
+func TestListTagsUnreadable(t *testing.T) {
+	client := newFakeClient(t)
+	client.describeErrors = map[string]error{
+		"dev-app-api": &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"},
+	}
+
+	var out string
+	var err error
+	stderr := captureStderr(t, func() { out, err = runTagList(t, "name", false, []string{"Owner=team-api"}, nil) })
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+	if out != "prod-app-api\n" {
+		t.Errorf("got %q, want only prod-app-api", out)
+	}
+	if !strings.Contains(stderr, "tags of 1 of 4 stacks could not be read") || !strings.Contains(stderr, "dev-app-api: access denied") {
+		t.Errorf("expected a warning naming dev-app-api, got %q", stderr)
+	}
+
+	// ListCmd resets the flags, so --strict is set after it
+	cmd := ListCmd()
+	tagFilterExprs = []string{"Owner=team-api"}
+	strictSearch = true
+	t.Cleanup(func() {
+		tagFilterExprs = nil
+		strictSearch = false
+	})
+	captureStderr(t, func() { captureStdout(t, func() { err = runList(cmd, nil) }) })
+	if ExitCode(err) != exitAuthError {
+		t.Errorf("expected exit code %d under --strict, got %v", exitAuthError, err)
+	}
+}
+
+func TestListShowTagColumns(t *testing.T) {
+	newFakeClient(t)
+	out, err := runTagList(t, "", false, []string{"Owner=team-api"}, []string{"Owner", "Environment"})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", out)
+	}
+	header := strings.Fields(lines[0])
+	wantHeader := []string{"NAME", "STATUS", "CREATED", "LAST", "UPDATED", "AGE", "OWNER", "ENVIRONMENT", "DESCRIPTION"}
+	if strings.Join(header, " ") != strings.Join(wantHeader, " ") {
+		t.Errorf("header = %v, want %v", header, wantHeader)
+	}
+	if !strings.Contains(lines[2], "team-api") || !strings.Contains(lines[2], "dev") {
+		t.Errorf("expected tag values in row: %q", lines[2])
+	}
+}
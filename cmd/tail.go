@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,126 +16,736 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// tailOptions are the flags of `cfn tail`.
+type tailOptions struct {
+	interval      time.Duration
+	recursive     bool
+	untilComplete bool
+	timeout       time.Duration
+	match         []string
+	dashboard     bool
+}
+
 func TailCmd() *cobra.Command {
 	var interval int
+	var timeout string
+	var opts tailOptions
 
 	cmd := &cobra.Command{
-		Use:   "tail <stack-name>",
+		Use:   "tail [stack-name...]",
 		Short: "Stream stack events in real time (Ctrl-C to stop)",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runTail(args[0], time.Duration(interval)*time.Second)
+		Long: `Stream stack events in real time (Ctrl-C to stop).
+
+Columns are sized to fit the terminal; piped or redirected output is never
+cut short. Unless --color=never or NO_COLOR is set, statuses are coloured: green when complete, yellow in progress, red
+failed and magenta rolling back.
+
+Several stacks can be tailed at once, by name or with --match, which takes
+the same substring or glob patterns as list. Their events are merged into one
+stream with a STACK column, each stack in its own colour on a terminal.
+Matching stacks are followed from the start of their current operation, and
+stacks that start an operation while tailing are picked up as they do.
+
+With --recursive the events of nested stacks are streamed too, with a STACK
+column. Nested stacks that exist when tailing starts are found through the
+stack's resources, and nested stacks created or updated later through the
+events of their parents.
+
+With --until-complete tail stops once the stack reaches a final status and
+prints a summary of the operation on stderr: its duration, the resources
+created, updated and deleted, and the root cause of any failure. It exits 0
+when the operation succeeded, 8 when it failed or rolled back and 9 when
+--timeout expires first, so it can wait for a deployment in CI. A stack
+that is not in the middle of an operation is reported right away. With
+several stacks tail waits for all of them, and fails if any of them failed.
+
+Events are polled every --interval seconds while an operation is in
+progress and every 30 seconds otherwise. Only the events newer than the
+ones already shown are fetched, and polling backs off when AWS throttles
+requests.
+
+With --dashboard the screen shows every resource of a single stack instead,
+with its status and how long it has been in it, a progress bar and the latest
+events, redrawn on every poll. While an update is in progress the bar counts
+the resources it has completed out of those it has started on so far, as the
+others are left alone; a create or delete counts out of the resources in the
+template, and once the operation is over every complete resource counts. It
+can be combined with --until-complete.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval < 1 {
+				return fmt.Errorf("--interval must be at least 1 second")
+			}
+			opts.interval = time.Duration(interval) * time.Second
+			if timeout != "" {
+				d, err := parseAge(timeout)
+				if err != nil {
+					return fmt.Errorf("invalid --timeout: %w", err)
+				}
+				opts.timeout = d
+			}
+			return runTail(args, opts)
 		},
 	}
 
-	cmd.Flags().IntVarP(&interval, "interval", "s", 5, "Polling interval in seconds")
+	cmd.Flags().IntVarP(&interval, "interval", "s", 5, "Polling interval in seconds (at least 1) during an operation; idle stacks are polled every 30s")
+	cmd.Flags().BoolVar(&opts.recursive, "recursive", false, "Include the events of nested stacks")
+	cmd.Flags().BoolVar(&opts.untilComplete, "until-complete", false, "Stop when the stack operation finishes and exit with its outcome")
+	cmd.Flags().StringVar(&timeout, "timeout", "", "With --until-complete, give up after this long (e.g. 30m, 2h)")
+	cmd.Flags().BoolVar(&opts.dashboard, "dashboard", false, "Show a full-screen view of the stack's resources and progress instead of a log")
+	cmd.Flags().StringArrayVar(&opts.match, "match", nil, "Tail the stacks whose names match, including ones that start an operation later (repeatable)")
 
 	return cmd
 }
 
-func runTail(stackName string, interval time.Duration) {
+// tailedStack is a stack being polled, by name or ID, with the events already
+// shown for it. Each stack has its own tracker because events of different
+// stacks are not ordered with respect to each other across polls.
+type tailedStack struct {
+	name    string
+	tracker *eventTracker
+	// waited is set for the stacks --until-complete waits for: the ones named
+	// or matched, not their nested stacks.
+	waited bool
+	status string // the latest status of the stack's own events
+	done   bool
+}
+
+// tailer polls a set of stacks that can grow while tailing.
+type tailer struct {
+	client    cloudFormationAPI
+	opts      tailOptions
+	names     *nameMatcher // --match, nil without it
+	showStack bool
+	stacks    []*tailedStack
+	known     map[string]bool // IDs of the stacks being tailed
+	colors    stackPalette
+	throttled int // polls in a row that were throttled
+}
+
+func runTail(stackNames []string, opts tailOptions) error {
+	if outputFormat != outputTable && outputFormat != outputWide && outputFormat != outputJSON {
+		return fmt.Errorf("output format %q is not supported by tail (use json for one event per line)", outputFormat)
+	}
+	if opts.timeout > 0 && !opts.untilComplete {
+		return fmt.Errorf("--timeout requires --until-complete")
+	}
+	if len(stackNames) == 0 && len(opts.match) == 0 {
+		return fmt.Errorf("specify at least one stack name or --match")
+	}
+	if opts.dashboard {
+		switch {
+		case len(stackNames) != 1 || len(opts.match) > 0 || opts.recursive:
+			return fmt.Errorf("--dashboard takes a single stack and cannot be combined with --match or --recursive")
+		case outputFormat == outputJSON:
+			return fmt.Errorf("--dashboard cannot be combined with --output json")
+		case !isTerminal(os.Stdout):
+			return fmt.Errorf("--dashboard requires a terminal")
+		}
+	}
+
+	t := &tailer{
+		opts:      opts,
+		showStack: opts.recursive || len(stackNames) > 1 || len(opts.match) > 0,
+		known:     make(map[string]bool),
+	}
+	if len(opts.match) > 0 {
+		names, err := newNameMatcher(opts.match, nil, false, false)
+		if err != nil {
+			return err
+		}
+		t.names = names
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	if opts.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, opts.timeout)
+		defer cancelTimeout()
+	}
 
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+	t.client = client
 
-	// Seed: remember the timestamp of the most recent event so we only show new ones.
-	var since time.Time
-	var initialEvent *types.StackEvent
-	seenEventIDs := make(map[string]struct{})
-	{
-		events, err := listEvents(ctx, client, stackName, 1)
+	// Seed: remember the most recent event of each stack so we only show new ones.
+	var initial []types.StackEvent
+	for _, name := range stackNames {
+		e, err := t.add(ctx, name)
 		if err != nil {
-			fatalf("failed to get initial events: %v\n", err)
+			return err
 		}
-		if len(events) > 0 && events[0].Timestamp != nil {
-			initialEvent = &events[0]
-			since = *events[0].Timestamp
-			if id := getValue(events[0].EventId); id != "" {
-				seenEventIDs[id] = struct{}{}
-			}
+		if e != nil {
+			initial = append(initial, *e)
 		}
 	}
+	if t.names != nil {
+		if err := t.pickUp(ctx); err != nil {
+			return fmt.Errorf("failed to list stacks: %w", err)
+		}
+	}
+	if opts.untilComplete && t.allDone() {
+		return t.finish()
+	}
 
-	fmt.Printf("Tailing events for stack %q (Ctrl-C to stop)...\n\n", stackName)
-	if !noHeaders {
-		fmt.Printf("%-22s %-40s %-45s %-30s %s\n", "TIMESTAMP", "LOGICAL ID", "TYPE", "STATUS", "REASON")
-		fmt.Printf("%-22s %-40s %-45s %-30s %s\n",
-			"──────────────────────", "────────────────────────────────────────",
-			"─────────────────────────────────────────────", "──────────────────────────────", "──────")
+	if opts.dashboard {
+		return t.runDashboard(ctx)
+	}
+
+	switch {
+	case len(stackNames) == 1 && t.names == nil:
+		fmt.Fprintf(os.Stderr, "Tailing events for stack %q (Ctrl-C to stop)...\n\n", stackNames[0])
+	case t.names != nil:
+		fmt.Fprintf(os.Stderr, "Tailing events for %d stacks and stacks matching %s (Ctrl-C to stop)...\n\n", len(stackNames), strings.Join(opts.match, ", "))
+	default:
+		fmt.Fprintf(os.Stderr, "Tailing events for %d stacks (Ctrl-C to stop)...\n\n", len(stackNames))
+	}
+	if !noHeaders && outputFormat != outputJSON {
+		printTailHeader(t.showStack)
 	}
 
-	if initialEvent != nil {
-		ts := initialEvent.Timestamp.Format("2006-01-02 15:04:05")
-		fmt.Printf("%-22s %-40s %-45s %-30s %s\n",
-			ts,
-			truncate(getValue(initialEvent.LogicalResourceId), 40),
-			truncate(getValue(initialEvent.ResourceType), 45),
-			truncate(string(initialEvent.ResourceStatus), 30),
-			getValue(initialEvent.ResourceStatusReason),
-		)
+	sortEvents(initial, false)
+	for _, e := range initial {
+		t.print(e)
 	}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(t.pollInterval())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Println("\nStopped.")
-			return
-		case <-ticker.C:
-			events, err := listEvents(ctx, client, stackName, 0)
-			if err != nil {
-				if ctx.Err() != nil || errors.Is(err, context.Canceled) {
-					continue
-				}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return t.timedOut()
+			}
+			fmt.Fprintln(os.Stderr, "\nStopped.")
+			return nil
+		case <-timer.C:
+			for _, e := range t.poll(ctx) {
+				t.print(e)
+			}
+			if opts.untilComplete && t.allDone() {
+				return t.finish()
+			}
+			timer.Reset(t.pollInterval())
+		}
+	}
+}
+
+// add starts tailing a named stack from its latest event, which it returns.
+// Waiting for an operation polls by ID, so a deleted stack's last events can
+// still be read.
+func (t *tailer) add(ctx context.Context, stackName string) (*types.StackEvent, error) {
+	stack, err := describeStack(ctx, t.client, stackName)
+	if err != nil {
+		return nil, err
+	}
+	id := getValue(stack.StackId)
+	if t.known[id] {
+		return nil, nil
+	}
+	t.known[id] = true
+
+	s := &tailedStack{name: stackName, tracker: newEventTracker(), waited: true, status: string(stack.StackStatus)}
+	if t.opts.untilComplete {
+		s.name = id
+		s.done, _ = operationOutcome(s.status)
+	}
+	t.stacks = append(t.stacks, s)
+
+	var initial *types.StackEvent
+	events, err := listEvents(ctx, t.client, s.name, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get initial events: %w", err)
+	}
+	if len(events) > 0 && events[0].Timestamp != nil {
+		initial = &events[0]
+		s.tracker.markSeen(events[0])
+	}
+	if t.opts.recursive {
+		t.stacks = append(t.stacks, seedNestedStacks(ctx, t.client, s.name, t.known)...)
+	}
+	return initial, nil
+}
+
+// pickUp starts tailing the stacks matching --match that are in the middle of
+// an operation, from the start of that operation. Nested stacks are left to
+// their parents with --recursive.
+func (t *tailer) pickUp(ctx context.Context) error {
+	var statuses []types.StackStatus
+	for _, status := range buildStatusFilters(false, false, false, true) {
+		// A stack waiting for a change set to be executed is not deploying
+		if status != types.StackStatusReviewInProgress {
+			statuses = append(statuses, status)
+		}
+	}
+	summaries, err := listStacks(ctx, t.client, statuses, t.names, "", "", false)
+	if err != nil {
+		return err
+	}
+
+	for _, summary := range summaries {
+		id := getValue(summary.StackId)
+		if t.known[id] || (t.opts.recursive && isNestedStack(summary)) {
+			continue
+		}
+		t.known[id] = true
+
+		s := &tailedStack{name: id, tracker: newEventTracker(), waited: true, status: string(summary.StackStatus)}
+		if since := operationStartTime(ctx, t.client, summary); since != nil {
+			s.tracker.since = *since
+		}
+		t.stacks = append(t.stacks, s)
+	}
+	return nil
+}
+
+// operationStartLookback is how far before a stack's last update or deletion
+// time its events are searched for the operation's "User Initiated" event,
+// which can be a little earlier.
+const operationStartLookback = 5 * time.Minute
+
+// operationStartTime returns the time of the event that started the operation
+// a stack is in the middle of, so that tailing it from there shows that event
+// too. Operations not started by a user, such as a nested stack's, fall back
+// to the stack's last update or deletion time.
+func operationStartTime(ctx context.Context, client cloudFormationAPI, summary types.StackSummary) *time.Time {
+	since := stackActivityTime(summary)
+	if summary.DeletionTime != nil {
+		since = summary.DeletionTime
+	}
+	if since == nil {
+		return nil
+	}
+	limit := since.Add(-operationStartLookback)
+	events, err := listEventsUntil(ctx, client, getValue(summary.StackId), func(e types.StackEvent) bool {
+		return isOperationStart(e) || eventTime(e).Before(limit)
+	})
+	if n := len(events); err == nil && n > 0 && isOperationStart(events[n-1]) && events[n-1].Timestamp != nil {
+		return events[n-1].Timestamp
+	}
+	return since
+}
+
+// poll fetches the new events of every stack and returns them oldest first.
+func (t *tailer) poll(ctx context.Context) []types.StackEvent {
+	if t.names != nil {
+		if err := t.pickUp(ctx); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to list stacks: %v\n", err)
+		}
+	}
+
+	var fresh []types.StackEvent
+	throttled := false
+	// Stacks discovered during the loop are polled in the same tick
+	for i := 0; i < len(t.stacks); i++ {
+		s := t.stacks[i]
+		if s.done {
+			continue
+		}
+		// Only the pages down to the events already shown are fetched
+		events, err := listEventsSince(ctx, t.client, s.name, s.tracker.since)
+		if err != nil {
+			switch {
+			case ctx.Err() != nil || errors.Is(err, context.Canceled):
+			case ExitCode(err) == exitThrottled:
+				throttled = true
+			default:
 				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
-				continue
 			}
+			continue
+		}
 
-			// Events are newest-first; collect those newer than `since`.
-			// Include equal-timestamp events when their EventId hasn't been seen yet.
-			var newEvents []types.StackEvent
-			for _, e := range events {
-				if e.Timestamp == nil {
-					continue
-				}
+		newEvents := s.tracker.newEvents(events)
+		fresh = append(fresh, newEvents...)
+		for _, e := range newEvents {
+			if isStackEvent(e) {
+				s.status = string(e.ResourceStatus)
+			}
+		}
+		if t.opts.untilComplete && s.waited {
+			s.done, _ = operationOutcome(s.status)
+		}
+		if t.opts.recursive {
+			t.stacks = append(t.stacks, discoverNestedStacks(newEvents, t.known)...)
+		}
+	}
 
-				if e.Timestamp.After(since) {
-					newEvents = append(newEvents, e)
-					continue
-				}
+	if throttled {
+		t.throttled++
+		fmt.Fprintf(os.Stderr, "warning: throttled by AWS, polling again in %s\n", t.pollInterval())
+	} else {
+		t.throttled = 0
+	}
 
-				if e.Timestamp.Equal(since) {
-					if id := getValue(e.EventId); id != "" {
-						if _, exists := seenEventIDs[id]; !exists {
-							newEvents = append(newEvents, e)
-						}
-					}
-				}
+	sortEvents(fresh, false)
+	return fresh
+}
+
+// Bounds of the polling interval: stacks without an operation in progress are
+// polled at most every idlePollInterval (or --interval when longer), and
+// throttled polls back off up to maxPollInterval (or --interval when longer).
+const (
+	idlePollInterval = 30 * time.Second
+	maxPollInterval  = 2 * time.Minute
+)
+
+// pollInterval returns how long to wait for the next poll: --interval while
+// an operation is in progress, longer while idle, and doubled for every poll
+// in a row that was throttled. It is never shorter than --interval.
+func (t *tailer) pollInterval() time.Duration {
+	d := t.opts.interval
+	if !t.inProgress() {
+		d = max(d, idlePollInterval)
+	}
+	limit := max(maxPollInterval, t.opts.interval)
+	for i := 0; i < t.throttled && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// inProgress reports whether a stack being tailed is in the middle of an
+// operation.
+func (t *tailer) inProgress() bool {
+	for _, s := range t.stacks {
+		if !s.done && strings.HasSuffix(s.status, "_IN_PROGRESS") {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tailer) print(e types.StackEvent) {
+	color := colorEnabled(os.Stdout)
+	stackColor := ""
+	if t.showStack && color {
+		stackColor = t.colors.color(getValue(e.StackName))
+	}
+	printTailEvent(os.Stdout, e, t.showStack, color, stackColor, terminalWidth(os.Stdout))
+}
+
+func (t *tailer) allDone() bool {
+	for _, s := range t.stacks {
+		if s.waited && !s.done {
+			return false
+		}
+	}
+	return true
+}
+
+// finish prints the summary of every waited-for stack's last operation and
+// fails if any of them did not succeed.
+func (t *tailer) finish() error {
+	if len(t.stacks) == 0 {
+		fmt.Fprintf(os.Stderr, "No stacks matching %s are in progress.\n", strings.Join(t.opts.match, ", "))
+		return nil
+	}
+	var failed []string
+	for _, s := range t.stacks {
+		if !s.waited {
+			continue
+		}
+		t.printSummary(s)
+		if _, succeeded := operationOutcome(s.status); !succeeded {
+			failed = append(failed, fmt.Sprintf("%s (%s)", stackNameFromID(s.name), s.status))
+		}
+	}
+	switch {
+	case len(failed) == 1:
+		return withExitCode(exitOperationFailed, fmt.Errorf("stack %s did not complete successfully", failed[0]))
+	case len(failed) > 1:
+		return withExitCode(exitOperationFailed, fmt.Errorf("stacks did not complete successfully: %s", strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+func (t *tailer) timedOut() error {
+	var waiting []string
+	for _, s := range t.stacks {
+		if s.waited && !s.done {
+			t.printSummary(s)
+			waiting = append(waiting, stackNameFromID(s.name))
+		}
+	}
+	return withExitCode(exitTimeout, fmt.Errorf("timed out after %s waiting for %s", t.opts.timeout, strings.Join(waiting, ", ")))
+}
+
+func (t *tailer) printSummary(s *tailedStack) {
+	prefix := ""
+	if t.showStack {
+		prefix = stackNameFromID(s.name) + ": "
+	}
+	printOperationSummary(t.client, s.name, prefix)
+}
+
+// operationOutcome tells whether a stack status is final and, if so, whether
+// the operation succeeded rather than failed or rolled back.
+func operationOutcome(status string) (done, succeeded bool) {
+	if status == "" || strings.HasSuffix(status, "_IN_PROGRESS") {
+		return false, false
+	}
+	switch types.StackStatus(status) {
+	case types.StackStatusCreateComplete, types.StackStatusUpdateComplete,
+		types.StackStatusDeleteComplete, types.StackStatusImportComplete:
+		return true, true
+	}
+	return true, false
+}
+
+// printOperationSummary prints the summary line of the stack's last operation
+// and the root causes of its failures on stderr. The summary is best effort:
+// the outcome has already been decided.
+func printOperationSummary(client cloudFormationAPI, stackID, prefix string) {
+	// The tail context may have expired or been cancelled by now
+	ctx := context.Background()
+	events, err := listEventsUntil(ctx, client, stackID, isOperationStart)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to summarise the operation: %v\n", err)
+		return
+	}
+	ops := groupOperations(events, stackID)
+	if len(ops) == 0 {
+		return
+	}
+	op := ops[0]
+	fmt.Fprintf(os.Stderr, "\n%s%s\n", prefix, operationSummary(op))
+
+	var since time.Time
+	if op.StartTime != nil {
+		since = *op.StartTime
+	}
+	causes := findRootCauses(ctx, client, events, since, []string{stackNameFromID(stackID)}, map[string]bool{stackID: true})
+	if len(causes) > 0 {
+		fmt.Fprintln(os.Stderr)
+		printRootCauses(os.Stderr, causes)
+	}
+}
+
+// seedNestedStacks finds the stacks currently nested in a stack, recursively,
+// and starts tracking each of them from its latest event.
+func seedNestedStacks(ctx context.Context, client cloudFormationAPI, stackName string, known map[string]bool) []*tailedStack {
+	tree := buildStackTree(ctx, client, stackName, stackName, map[string]bool{})
+	if tree.Error != "" {
+		fmt.Fprintf(os.Stderr, "warning: failed to list nested stacks: %s\n", tree.Error)
+	}
+
+	var stacks []*tailedStack
+	var walk func(t *stackTree)
+	walk = func(t *stackTree) {
+		for _, r := range t.Resources {
+			if r.Stack == nil {
+				continue
 			}
+			known[r.Stack.StackId] = true
+			s := &tailedStack{name: r.Stack.StackId, tracker: newEventTracker()}
+			if events, err := listEvents(ctx, client, s.name, 1); err == nil && len(events) > 0 {
+				s.tracker.markSeen(events[0])
+			}
+			stacks = append(stacks, s)
+			walk(r.Stack)
+		}
+	}
+	walk(tree)
+	return stacks
+}
 
-			for i := len(newEvents) - 1; i >= 0; i-- {
-				e := newEvents[i]
-				if id := getValue(e.EventId); id != "" {
-					seenEventIDs[id] = struct{}{}
-				}
-				ts := ""
-				if e.Timestamp != nil {
-					ts = e.Timestamp.Format("2006-01-02 15:04:05")
-					if e.Timestamp.After(since) {
-						since = *e.Timestamp
-					}
+// discoverNestedStacks returns trackers for the nested stacks that new events
+// (oldest first) mention for the first time. Their events are shown from the
+// first such mention on: a nested stack's events follow its parent's event
+// that started the nested operation.
+func discoverNestedStacks(events []types.StackEvent, known map[string]bool) []*tailedStack {
+	var stacks []*tailedStack
+	for _, e := range events {
+		id := nestedStackID(e)
+		if id == "" || known[id] || e.Timestamp == nil {
+			continue
+		}
+		known[id] = true
+		tracker := newEventTracker()
+		tracker.since = *e.Timestamp
+		stacks = append(stacks, &tailedStack{name: id, tracker: tracker})
+	}
+	return stacks
+}
+
+// eventTracker remembers which events have already been shown so that
+// repeated polls of the full event list only yield the new ones.
+type eventTracker struct {
+	since time.Time
+	seen  map[string]struct{}
+}
+
+func newEventTracker() *eventTracker {
+	return &eventTracker{seen: make(map[string]struct{})}
+}
+
+func (t *eventTracker) markSeen(e types.StackEvent) {
+	if id := getValue(e.EventId); id != "" {
+		t.seen[id] = struct{}{}
+	}
+	if e.Timestamp != nil && e.Timestamp.After(t.since) {
+		t.since = *e.Timestamp
+	}
+}
+
+// newEvents takes events newest-first, as returned by DescribeStackEvents, and
+// returns the unseen ones oldest-first, marking them as seen.
+func (t *eventTracker) newEvents(events []types.StackEvent) []types.StackEvent {
+	// Collect events newer than `since`. Include equal-timestamp events when
+	// their EventId hasn't been seen yet.
+	var fresh []types.StackEvent
+	for _, e := range events {
+		if e.Timestamp == nil {
+			continue
+		}
+
+		if e.Timestamp.After(t.since) {
+			fresh = append(fresh, e)
+			continue
+		}
+
+		if e.Timestamp.Equal(t.since) {
+			if id := getValue(e.EventId); id != "" {
+				if _, exists := t.seen[id]; !exists {
+					fresh = append(fresh, e)
 				}
-				fmt.Printf("%-22s %-40s %-45s %-30s %s\n",
-					ts,
-					truncate(getValue(e.LogicalResourceId), 40),
-					truncate(getValue(e.ResourceType), 45),
-					truncate(string(e.ResourceStatus), 30),
-					getValue(e.ResourceStatusReason),
-				)
 			}
 		}
 	}
+
+	out := make([]types.StackEvent, 0, len(fresh))
+	for i := len(fresh) - 1; i >= 0; i-- {
+		t.markSeen(fresh[i])
+		out = append(out, fresh[i])
+	}
+	return out
+}
+
+// tailColumn is one of tail's fixed-width columns. Columns are shrunk from
+// their ideal width down to min to fit the terminal.
+type tailColumn struct {
+	title      string
+	ideal, min int
+}
+
+var stackColumn = tailColumn{"STACK", 35, 12}
+
+// eventColumns are the columns of every event line, after STACK and before
+// REASON.
+var eventColumns = []tailColumn{
+	{"TIMESTAMP", 19, 19},
+	{"LOGICAL ID", 40, 16},
+	{"TYPE", 45, 20},
+	{"STATUS", 44, 18},
+}
+
+// minReasonWidth is the least room left for REASON before the other columns
+// are shrunk.
+const minReasonWidth = 30
+
+// tailColumns returns the titles and widths of tail's columns for a terminal
+// width columns wide, or of unknown width when 0. The last column, REASON,
+// takes the rest of the line and is not padded; its width is 0 when
+// unlimited. STACK comes first so the lines of different stacks are easy to
+// tell apart.
+func tailColumns(showStack bool, width int) ([]string, []int) {
+	columns := eventColumns
+	if showStack {
+		columns = append([]tailColumn{stackColumn}, columns...)
+	}
+
+	titles := make([]string, 0, len(columns)+1)
+	widths := make([]int, 0, len(columns)+1)
+	total, slack := 0, 0
+	for _, c := range columns {
+		titles = append(titles, c.title)
+		widths = append(widths, c.ideal)
+		total += c.ideal + 1
+		slack += c.ideal - c.min
+	}
+	titles = append(titles, "REASON")
+	if width <= 0 {
+		return titles, append(widths, 0)
+	}
+
+	// Shrink each column in proportion to how much it can give
+	if excess := total + minReasonWidth - width; excess > 0 && slack > 0 {
+		for i, c := range columns {
+			cut := (excess*(c.ideal-c.min) + slack - 1) / slack
+			widths[i] = max(c.ideal-cut, c.min)
+			total -= c.ideal - widths[i]
+		}
+	}
+	return titles, append(widths, max(width-total, minReasonWidth))
+}
+
+func printTailHeader(showStack bool) {
+	titles, widths := tailColumns(showStack, terminalWidth(os.Stdout))
+	rules := make([]string, len(widths))
+	for i, w := range widths {
+		if w == 0 {
+			w = len(titles[i])
+		}
+		rules[i] = strings.Repeat("─", w)
+	}
+	printTailLine(os.Stdout, titles, widths, nil)
+	printTailLine(os.Stdout, rules, widths, nil)
+}
+
+// printTailLine prints cells padded to their widths, except for the last one
+// which is only truncated, each in the colour at the same index of colors.
+func printTailLine(w io.Writer, cells []string, widths []int, colors []string) {
+	color := func(i int, s string) string {
+		if i < len(colors) {
+			return colorize(s, colors[i], true)
+		}
+		return s
+	}
+	last := len(cells) - 1
+	for i, cell := range cells[:last] {
+		fmt.Fprint(w, color(i, fmt.Sprintf("%-*s", widths[i], truncate(cell, widths[i]))), " ")
+	}
+	reason := cells[last]
+	if widths[last] > 0 {
+		reason = truncate(reason, widths[last])
+	}
+	fmt.Fprintln(w, color(last, reason))
+}
+
+// printTailEvent prints a single event line fitted to width (see
+// tailColumns), or a compact JSON document per event with --output json so
+// the stream can be consumed line by line. With color set the status is
+// coloured, and so is the stack name in stackColor.
+func printTailEvent(w io.Writer, e types.StackEvent, showStack, color bool, stackColor string, width int) {
+	if outputFormat == outputJSON {
+		data, err := json.Marshal(e)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			return
+		}
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	var cells, colors []string
+	if showStack {
+		cells = append(cells, getValue(e.StackName))
+		colors = append(colors, stackColor)
+	}
+	cells = append(cells,
+		formatTime(e.Timestamp),
+		getValue(e.LogicalResourceId),
+		getValue(e.ResourceType),
+		string(e.ResourceStatus),
+		getValue(e.ResourceStatusReason),
+	)
+	if !color {
+		colors = nil
+	} else {
+		colors = append(colors, "", "", "", statusColor(string(e.ResourceStatus)))
+	}
+	_, widths := tailColumns(showStack, width)
+	printTailLine(w, cells, widths, colors)
 }
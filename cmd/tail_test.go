@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+func tailEvent(id string, minute int) types.StackEvent {
+	ts := time.Date(2024, 6, 15, 8, minute, 0, 0, time.UTC)
+	return types.StackEvent{EventId: aws.String(id), Timestamp: &ts}
+}
+
+func eventIDs(events []types.StackEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, getValue(e.EventId))
+	}
+	return ids
+}
+
+func TestEventTrackerDedup(t *testing.T) {
+	tracker := newEventTracker()
+	tracker.markSeen(tailEvent("a", 0))
+
+	// Nothing new yet
+	if got := tracker.newEvents([]types.StackEvent{tailEvent("a", 0)}); len(got) != 0 {
+		t.Fatalf("expected no new events, got %v", eventIDs(got))
+	}
+
+	// New events come back oldest-first; same-timestamp siblings of the seed are kept
+	poll := []types.StackEvent{tailEvent("d", 2), tailEvent("c", 1), tailEvent("b", 0), tailEvent("a", 0)}
+	if got, want := eventIDs(tracker.newEvents(poll)), []string{"b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Re-polling the same list yields nothing
+	if got := tracker.newEvents(poll); len(got) != 0 {
+		t.Fatalf("expected no new events on re-poll, got %v", eventIDs(got))
+	}
+
+	// A late event with the same timestamp as the newest one is still shown once
+	poll = append([]types.StackEvent{tailEvent("e", 2)}, poll...)
+	if got, want := eventIDs(tracker.newEvents(poll)), []string{"e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := tracker.newEvents(poll); len(got) != 0 {
+		t.Fatalf("expected no new events on re-poll, got %v", eventIDs(got))
+	}
+}
+
+func TestEventTrackerIgnoresOlderAndUntimedEvents(t *testing.T) {
+	tracker := newEventTracker()
+	tracker.markSeen(tailEvent("b", 5))
+
+	poll := []types.StackEvent{{EventId: aws.String("untimed")}, tailEvent("b", 5), tailEvent("a", 1)}
+	if got := tracker.newEvents(poll); len(got) != 0 {
+		t.Fatalf("expected no new events, got %v", eventIDs(got))
+	}
+}
+
+func TestDiscoverNestedStacks(t *testing.T) {
+	const parentID = "arn:aws:cloudformation:us-east-1:111111111111:stack/app/1"
+	const nestedID = "arn:aws:cloudformation:us-east-1:111111111111:stack/app-Db-X/2"
+	event := func(id string, minute int, physicalID string) types.StackEvent {
+		e := tailEvent(id, minute)
+		e.StackId = aws.String(parentID)
+		e.ResourceType = aws.String(nestedStackType)
+		e.PhysicalResourceId = aws.String(physicalID)
+		return e
+	}
+
+	known := make(map[string]bool)
+	events := []types.StackEvent{
+		event("self", 0, parentID), // the parent's own event
+		event("create", 1, ""),     // no physical ID yet
+		event("initiated", 2, nestedID),
+		event("complete", 3, nestedID),
+	}
+	stacks := discoverNestedStacks(events, known)
+	if len(stacks) != 1 || stacks[0].name != nestedID {
+		t.Fatalf("expected one nested stack, got %+v", stacks)
+	}
+	// Events of the nested stack from the first mention on are shown
+	poll := []types.StackEvent{tailEvent("n2", 3), tailEvent("n1", 2), tailEvent("old", 1)}
+	if got, want := eventIDs(stacks[0].tracker.newEvents(poll)), []string{"n1", "n2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if again := discoverNestedStacks(events, known); len(again) != 0 {
+		t.Errorf("expected known stacks to be skipped, got %+v", again)
+	}
+}
+
+func runTailUntilComplete(t *testing.T, stackName string, timeout time.Duration) (stdout, stderr string, err error) {
+	t.Helper()
+	return runTailOutput(t, []string{stackName}, tailOptions{untilComplete: true, timeout: timeout})
+}
+
+func runTailOutput(t *testing.T, stackNames []string, opts tailOptions) (stdout, stderr string, err error) {
+	t.Helper()
+	opts.interval = time.Millisecond
+	stdout = captureStdout(t, func() {
+		stderr = captureStderr(t, func() {
+			err = runTail(stackNames, opts)
+		})
+	})
+	return stdout, stderr, err
+}
+
+func TestTailUntilCompleteFinished(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	_, stderr, err := runTailUntilComplete(t, "prod-app-api", 0)
+	if err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	if want := "update started 2024-06-15 08:30:00, took 3m, UPDATE_COMPLETE (2 updated)"; !strings.Contains(stderr, want) {
+		t.Errorf("expected %q in:\n%s", want, stderr)
+	}
+
+	_, stderr, err = runTailUntilComplete(t, "shop", 0)
+	if ExitCode(err) != exitOperationFailed {
+		t.Fatalf("got exit code %d (%v), want %d", ExitCode(err), err, exitOperationFailed)
+	}
+	if !strings.Contains(stderr, "shop › Database › Cache › CacheCluster") {
+		t.Errorf("expected the root cause in:\n%s", stderr)
+	}
+}
+
+func TestTailUntilCompleteWaits(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	setOutput(t, "")
+
+	// The update progresses by one event per poll after the initial one
+	stack, _ := fake.find(aws.String("prod-app-api"))
+	stack.Stack.StackStatus = types.StackStatusUpdateInProgress
+	all := stack.Events
+	stack.Events = all[len(all)-1:]
+	polls := 0
+	fake.pollEvents = func(s *fakeStack) {
+		if polls++; polls > 1 && s == stack && len(s.Events) < len(all) {
+			s.Events = all[len(all)-len(s.Events)-1:]
+		}
+	}
+
+	stdout, _, err := runTailUntilComplete(t, "prod-app-api", time.Minute)
+	if err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2+len(all) || !strings.Contains(lines[len(lines)-1], "UPDATE_COMPLETE") {
+		t.Errorf("expected every event up to the final status:\n%s", stdout)
+	}
+}
+
+func TestTailUntilCompleteTimeout(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	setOutput(t, "")
+	stack, _ := fake.find(aws.String("prod-app-api"))
+	stack.Stack.StackStatus = types.StackStatusUpdateInProgress
+
+	_, _, err := runTailUntilComplete(t, "prod-app-api", 20*time.Millisecond)
+	if ExitCode(err) != exitTimeout {
+		t.Errorf("got exit code %d (%v), want %d", ExitCode(err), err, exitTimeout)
+	}
+}
+
+func TestTailTimeoutRequiresUntilComplete(t *testing.T) {
+	setOutput(t, "")
+	if err := runTail([]string{"prod-app-api"}, tailOptions{interval: time.Second, timeout: time.Minute}); err == nil {
+		t.Error("expected --timeout without --until-complete to fail")
+	}
+	if err := runTail(nil, tailOptions{interval: time.Second}); err == nil {
+		t.Error("expected tail without stacks to fail")
+	}
+}
+
+func TestTailMultipleStacks(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	_, stderr, err := runTailOutput(t, []string{"prod-app-api", "shop"}, tailOptions{untilComplete: true})
+	if ExitCode(err) != exitOperationFailed || !strings.Contains(err.Error(), "shop") {
+		t.Fatalf("got exit code %d (%v), want %d", ExitCode(err), err, exitOperationFailed)
+	}
+	for _, want := range []string{"prod-app-api: update started", "shop: update started"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestTailMatchPicksUpStacks(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	setOutput(t, "")
+
+	// prod-app-worker is deploying from the start; prod-app-api starts its
+	// update while tailing, and every event of that update is shown
+	api, _ := fake.find(aws.String("prod-app-api"))
+	worker, _ := fake.find(aws.String("prod-app-worker"))
+	fake.pollEvents = func(s *fakeStack) {
+		if s == worker {
+			api.Stack.StackStatus = types.StackStatusUpdateInProgress
+		}
+	}
+
+	stdout, stderr, err := runTailOutput(t, nil, tailOptions{match: []string{"prod-app-"}, untilComplete: true, timeout: 50 * time.Millisecond})
+	if ExitCode(err) != exitTimeout || !strings.Contains(err.Error(), "prod-app-worker") {
+		t.Fatalf("got exit code %d (%v), want %d", ExitCode(err), err, exitTimeout)
+	}
+	if !strings.Contains(stderr, "stacks matching prod-app-") {
+		t.Errorf("unexpected message:\n%s", stderr)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if !strings.HasPrefix(lines[0], "STACK ") {
+		t.Errorf("expected the STACK column first, got %q", lines[0])
+	}
+	if len(lines) != 2+len(api.Events) {
+		t.Fatalf("expected the update's %d events:\n%s", len(api.Events), stdout)
+	}
+	for _, line := range lines[2:] {
+		if !strings.HasPrefix(line, "prod-app-api ") {
+			t.Errorf("expected the stack name first, got %q", line)
+		}
+	}
+}
+
+func TestTailMatchKeepsOperationStart(t *testing.T) {
+	fake := newFakeClient(t)
+	setOutput(t, "")
+
+	// The stack's update time is a few seconds after its "User Initiated"
+	// event, which must still be shown
+	api, _ := fake.find(aws.String("prod-app-api"))
+	api.Stack.StackStatus = types.StackStatusUpdateInProgress
+	api.Stack.LastUpdatedTime = aws.Time(time.Date(2024, 6, 15, 8, 30, 5, 0, time.UTC))
+
+	stdout, _, err := runTailOutput(t, nil, tailOptions{match: []string{"prod-app-api"}, untilComplete: true, timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("runTail: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); len(lines) != 2+len(api.Events) || !strings.Contains(lines[2], "User Initiated") {
+		t.Errorf("expected the update's %d events from its start:\n%s", len(api.Events), stdout)
+	}
+}
+
+func TestTailColumns(t *testing.T) {
+	// Unknown width: ideal widths and an unlimited REASON
+	_, widths := tailColumns(false, 0)
+	if want := []int{19, 40, 45, 44, 0}; !reflect.DeepEqual(widths, want) {
+		t.Errorf("got %v, want %v", widths, want)
+	}
+	// Wide enough: REASON takes the rest
+	if _, widths = tailColumns(false, 200); widths[3] != 44 || widths[4] != 200-152 {
+		t.Errorf("unexpected widths %v", widths)
+	}
+
+	for _, width := range []int{130, 150, 180} {
+		titles, widths := tailColumns(true, width)
+		line := len(titles) - 1 // the spaces between columns
+		for i, w := range widths {
+			line += w
+			if c := append([]tailColumn{stackColumn}, eventColumns...); i < len(c) && w < c[i].min {
+				t.Errorf("width %d: %s shrunk to %d", width, titles[i], w)
+			}
+		}
+		if line != width || widths[len(widths)-1] < minReasonWidth {
+			t.Errorf("width %d: widths %v don't fit", width, widths)
+		}
+	}
+
+	// Too narrow: every column at its minimum
+	if _, widths = tailColumns(false, 40); !reflect.DeepEqual(widths, []int{19, 16, 20, 18, minReasonWidth}) {
+		t.Errorf("unexpected widths %v", widths)
+	}
+}
+
+func TestPrintTailEventColor(t *testing.T) {
+	setOutput(t, "")
+	e := tailEvent("a", 0)
+	e.StackName = aws.String("app")
+	e.LogicalResourceId = aws.String("Queue")
+	e.ResourceStatus = types.ResourceStatusUpdateFailed
+	e.ResourceStatusReason = aws.String("access denied")
+
+	var plain, colored strings.Builder
+	printTailEvent(&plain, e, true, false, stackColors[0], 0)
+	printTailEvent(&colored, e, true, true, stackColors[0], 0)
+	for _, want := range []string{stackColors[0] + "app ", ansiRed + "UPDATE_FAILED "} {
+		if !strings.Contains(colored.String(), want) {
+			t.Errorf("expected %q in %q", want, colored.String())
+		}
+	}
+	if got := ansiEscape.ReplaceAllString(colored.String(), ""); got != plain.String() {
+		t.Errorf("got %q, want %q", got, plain.String())
+	}
+}
+
+func TestStackPalette(t *testing.T) {
+	var p stackPalette
+	seen := make(map[string]bool)
+	for i := range stackColors {
+		c := p.color(fmt.Sprintf("stack-%d", i))
+		if seen[c] {
+			t.Errorf("colour %q handed out twice", c)
+		}
+		seen[c] = true
+	}
+	if p.color("stack-1") != stackColors[1] {
+		t.Error("expected a stack to keep its colour")
+	}
+	if p.color("one-more") != stackColors[0] {
+		t.Error("expected colours to cycle")
+	}
+}
+
+func TestTailPollsIncrementally(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	ctx := context.Background()
+
+	tail := &tailer{client: fake, opts: tailOptions{interval: time.Second}, known: make(map[string]bool)}
+	if _, err := tail.add(ctx, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	stack, _ := fake.find(aws.String("shop"))
+	next := stack.Events[0]
+	next.EventId = aws.String("shop-next")
+	stack.Events = append([]types.StackEvent{next}, stack.Events...)
+
+	before := fake.calls["DescribeStackEvents"]
+	if got := eventIDs(tail.poll(ctx)); !reflect.DeepEqual(got, []string{"shop-next"}) {
+		t.Errorf("got %v, want the new event", got)
+	}
+	// 16 events at 2 per page; listing stops at the second page, the first
+	// one with an event older than those already shown
+	if got := fake.calls["DescribeStackEvents"] - before; got != 2 {
+		t.Errorf("got %d DescribeStackEvents calls, want 2", got)
+	}
+}
+
+func TestTailBacksOffWhenThrottled(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	ctx := context.Background()
+
+	tail := &tailer{client: fake, opts: tailOptions{interval: time.Second}, known: make(map[string]bool)}
+	if _, err := tail.add(ctx, "shop"); err != nil {
+		t.Fatal(err)
+	}
+	fake.eventErrors = map[string]error{"shop": &smithy.GenericAPIError{Code: "ThrottlingException"}}
+
+	stderr := captureStderr(t, func() {
+		tail.poll(ctx)
+		tail.poll(ctx)
+	})
+	if tail.throttled != 2 || !strings.Contains(stderr, "throttled") {
+		t.Errorf("expected two throttled polls, got %d:\n%s", tail.throttled, stderr)
+	}
+	fake.eventErrors = nil
+	tail.poll(ctx)
+	if tail.throttled != 0 {
+		t.Errorf("expected a successful poll to reset the backoff")
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	stack := &tailedStack{status: "UPDATE_IN_PROGRESS"}
+	tail := &tailer{opts: tailOptions{interval: 5 * time.Second}, stacks: []*tailedStack{stack}}
+
+	for _, tt := range []struct {
+		status    string
+		throttled int
+		want      time.Duration
+	}{
+		{"UPDATE_IN_PROGRESS", 0, 5 * time.Second},
+		{"UPDATE_COMPLETE", 0, idlePollInterval},
+		{"UPDATE_IN_PROGRESS", 2, 20 * time.Second},
+		{"UPDATE_COMPLETE", 1, time.Minute},
+		{"UPDATE_IN_PROGRESS", 40, maxPollInterval},
+	} {
+		stack.status, tail.throttled = tt.status, tt.throttled
+		if got := tail.pollInterval(); got != tt.want {
+			t.Errorf("%s, throttled %d times: got %s, want %s", tt.status, tt.throttled, got, tt.want)
+		}
+	}
+
+	// An --interval longer than maxPollInterval is kept, throttled or not
+	tail.opts.interval = 5 * time.Minute
+	for _, throttled := range []int{0, 3} {
+		tail.throttled = throttled
+		if got := tail.pollInterval(); got != tail.opts.interval {
+			t.Errorf("throttled %d times: got %s, want %s", throttled, got, tail.opts.interval)
+		}
+	}
+}
+
+func TestTailIntervalValidated(t *testing.T) {
+	newFakeClient(t)
+	setOutput(t, "")
+	for _, interval := range []string{"0", "-1"} {
+		cmd := TailCmd()
+		cmd.SetArgs([]string{"prod-app-api", "--interval", interval})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--interval") {
+			t.Errorf("--interval %s: expected an error, got %v", interval, err)
+		}
+	}
+}
+
+func TestOperationOutcome(t *testing.T) {
+	for status, want := range map[string][2]bool{
+		"UPDATE_COMPLETE":                     {true, true},
+		"DELETE_COMPLETE":                     {true, true},
+		"UPDATE_ROLLBACK_COMPLETE":            {true, false},
+		"ROLLBACK_COMPLETE":                   {true, false},
+		"CREATE_FAILED":                       {true, false},
+		"UPDATE_COMPLETE_CLEANUP_IN_PROGRESS": {false, false},
+		"REVIEW_IN_PROGRESS":                  {false, false},
+	} {
+		if done, succeeded := operationOutcome(status); done != want[0] || succeeded != want[1] {
+			t.Errorf("operationOutcome(%s) = %v, %v, want %v", status, done, succeeded, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// defaultRegions are the commercial regions enabled by default in every
+// account, queried by --all-regions. Opt-in regions have to be listed
+// explicitly with --regions.
+var defaultRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"ca-central-1", "sa-east-1",
+	"eu-central-1", "eu-north-1", "eu-west-1", "eu-west-2", "eu-west-3",
+	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-southeast-1", "ap-southeast-2",
+}
+
+// buildTargets expands --profiles and --regions/--all-regions into the list
+// of accounts and regions to query. Without them it is the single target
+// selected by the global --profile and --region flags.
+func buildTargets(profiles, regions []string, allRegions bool) ([]target, error) {
+	if allRegions && len(regions) > 0 {
+		return nil, fmt.Errorf("--regions and --all-regions are mutually exclusive")
+	}
+	if allRegions {
+		regions = defaultRegions
+	}
+
+	if len(profiles) == 0 {
+		profiles = []string{awsOptions.Profile}
+	}
+	if len(regions) == 0 {
+		regions = []string{region}
+	}
+
+	var targets []target
+	seen := make(map[target]bool)
+	for _, p := range profiles {
+		for _, r := range regions {
+			t := target{profile: strings.TrimSpace(p), region: strings.TrimSpace(r)}
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// stackRef is a listed stack together with the client for the account and
+// region it was listed from, so follow-up calls go to the right place.
+type stackRef struct {
+	client  cloudFormationAPI
+	summary types.StackSummary
+	tags    map[string]string // only set by attachStackTags
+}
+
+func stackSummaries(refs []stackRef) []types.StackSummary {
+	stacks := make([]types.StackSummary, 0, len(refs))
+	for _, r := range refs {
+		stacks = append(stacks, r.summary)
+	}
+	return stacks
+}
+
+// listStacksAcross runs listStacks against every target concurrently and
+// merges the results in target order. A target that fails is reported on
+// stderr and skipped; the call only fails if every target did.
+func listStacksAcross(ctx context.Context, targets []target, statusFilters []types.StackStatus, names *nameMatcher, descContains, descNotContains string, ignoreCase bool) ([]stackRef, error) {
+	results := make([][]stackRef, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := getTargetClient(ctx, t)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			stacks, err := listStacks(ctx, client, statusFilters, names, descContains, descNotContains, ignoreCase)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to list stacks: %w", err)
+				return
+			}
+			for _, s := range stacks {
+				results[i] = append(results[i], stackRef{client: client, summary: s})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(targets) == 1 {
+		return results[0], errs[0]
+	}
+
+	var all []stackRef
+	var firstErr error
+	failed := 0
+	for i, t := range targets {
+		if errs[i] != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", t, errs[i])
+			}
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %v\n", t, errs[i])
+			continue
+		}
+		all = append(all, results[i]...)
+	}
+	if failed == len(targets) {
+		return nil, firstErr
+	}
+	return all, nil
+}
+
+// stackLocation extracts the account ID and region from a stack ARN,
+// e.g. arn:aws:cloudformation:us-east-1:123456789012:stack/name/id.
+func stackLocation(stackID string) (account, region string) {
+	parts := strings.SplitN(stackID, ":", 6)
+	if len(parts) < 6 {
+		return "", ""
+	}
+	return parts[4], parts[3]
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestBuildTargets(t *testing.T) {
+	tests := []struct {
+		name       string
+		profiles   []string
+		regions    []string
+		allRegions bool
+		want       []target
+		wantErr    bool
+	}{
+		{name: "defaults", want: []target{{}}},
+		{name: "regions", regions: []string{"us-east-1", " eu-west-1"}, want: []target{{region: "us-east-1"}, {region: "eu-west-1"}}},
+		{
+			name:     "profiles by regions",
+			profiles: []string{"dev", "prod"},
+			regions:  []string{"us-east-1", "eu-west-1", "us-east-1"},
+			want: []target{
+				{profile: "dev", region: "us-east-1"}, {profile: "dev", region: "eu-west-1"},
+				{profile: "prod", region: "us-east-1"}, {profile: "prod", region: "eu-west-1"},
+			},
+		},
+		{name: "regions and all regions", regions: []string{"us-east-1"}, allRegions: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTargets(tt.profiles, tt.regions, tt.allRegions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	all, err := buildTargets(nil, nil, true)
+	if err != nil || len(all) != len(defaultRegions) {
+		t.Errorf("--all-regions: got %d targets (err %v), want %d", len(all), err, len(defaultRegions))
+	}
+}
+
+func TestStackLocation(t *testing.T) {
+	account, region := stackLocation("arn:aws:cloudformation:eu-west-1:222222222222:stack/eu-app/1")
+	if account != "222222222222" || region != "eu-west-1" {
+		t.Errorf("got %q %q", account, region)
+	}
+	if account, region := stackLocation("not-an-arn"); account != "" || region != "" {
+		t.Errorf("got %q %q for an invalid ARN", account, region)
+	}
+}
+
+// newFanOutClients installs the fixture fake for us-east-1, a second account's
+// fake for eu-west-1 and a failing target for ap-south-1.
+func newFanOutClients(t *testing.T) (*fakeCloudFormation, *fakeCloudFormation) {
+	t.Helper()
+	us := newFakeClient(t)
+
+	created := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	eu := &fakeCloudFormation{
+		stacks: []*fakeStack{{Stack: types.Stack{
+			StackName:    aws.String("prod-app-api"),
+			StackId:      aws.String("arn:aws:cloudformation:eu-west-1:222222222222:stack/prod-app-api/1"),
+			StackStatus:  types.StackStatusCreateComplete,
+			Description:  aws.String("Production API service (EU)"),
+			CreationTime: &created,
+		}}},
+		templates:      map[string]string{"prod-app-api": us.templates["prod-app-api"]},
+		templateErrors: map[string]error{},
+		calls:          map[string]int{},
+	}
+
+	newClient = func(_ context.Context, tg target) (cloudFormationAPI, error) {
+		switch tg.region {
+		case "eu-west-1":
+			return eu, nil
+		case "ap-south-1":
+			return nil, errors.New("no credentials")
+		}
+		return us, nil
+	}
+	return us, eu
+}
+
+func TestListStacksAcross(t *testing.T) {
+	newFanOutClients(t)
+	targets := []target{{region: "us-east-1"}, {region: "ap-south-1"}, {region: "eu-west-1"}}
+
+	names, err := newNameMatcher([]string{"app-api"}, nil, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []stackRef
+	captureStderr(t, func() {
+		refs, err = listStacksAcross(context.Background(), targets, nil, names, "", "", false)
+	})
+	if err != nil {
+		t.Fatalf("listStacksAcross: %v", err)
+	}
+
+	var got []string
+	for _, r := range refs {
+		account, region := stackLocation(getValue(r.summary.StackId))
+		got = append(got, account+"/"+region+"/"+getValue(r.summary.StackName))
+	}
+	want := []string{
+		"111111111111/us-east-1/prod-app-api",
+		"111111111111/us-east-1/dev-app-api",
+		"222222222222/eu-west-1/prod-app-api",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := listStacksAcross(context.Background(), []target{{region: "ap-south-1"}, {region: "ap-south-1", profile: "x"}}, nil, nil, "", "", false); err == nil {
+		t.Error("expected an error when every target fails")
+	}
+}
+
+func TestRunListAcrossRegions(t *testing.T) {
+	us, eu := newFanOutClients(t)
+	setOutput(t, "")
+	cmd := ListCmd()
+	regions = []string{"us-east-1", "eu-west-1"}
+	resourceType = "AWS::SQS::Queue"
+	t.Cleanup(func() {
+		regions = nil
+		resourceType = ""
+		nameFilters = nil
+	})
+
+	var err error
+	out := captureStdout(t, func() {
+		captureStderr(t, func() { err = runList(cmd, []string{"prod-app-api"}) })
+	})
+	if err != nil {
+		t.Fatalf("runList: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ACCOUNT") || !strings.Contains(lines[0], "REGION") {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+	if !strings.Contains(lines[1], "111111111111") || !strings.Contains(lines[2], "eu-west-1") {
+		t.Errorf("unexpected rows:\n%s", out)
+	}
+	// Each stack's template is fetched from the region it was listed in
+	if us.calls["GetTemplate"] != 1 || eu.calls["GetTemplate"] != 1 {
+		t.Errorf("GetTemplate calls: us=%d eu=%d", us.calls["GetTemplate"], eu.calls["GetTemplate"])
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
-	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
 )
 
@@ -17,9 +15,19 @@ func TemplateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "template <stack-name>",
 		Short: "Fetch and print the deployed template for a stack",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runTemplate(args[0], pretty)
+		Long: `Fetch and print the deployed template for a stack.
+
+The template is printed as stored by CloudFormation. With --output json or
+--output yaml it is converted to that format instead; YAML short-form
+intrinsics such as !Ref and !GetAtt are written in their long form
+({"Ref": ...}, {"Fn::GetAtt": [...]}), which is also how resource searches
+see them.
+
+Templates are served from the local cache when the stack hasn't changed since
+they were fetched (see "cfn cache").`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplate(args[0], pretty)
 		},
 	}
 
@@ -28,19 +36,34 @@ func TemplateCmd() *cobra.Command {
 	return cmd
 }
 
-func runTemplate(stackName string, pretty bool) {
+func runTemplate(stackName string, pretty bool) error {
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	output, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
-		StackName:     &stackName,
-		TemplateStage: types.TemplateStageOriginal,
-	})
+	// Only use the cache when the stack can be described cheaply; fall back
+	// to fetching by name so the usual GetTemplate error is reported.
+	var key *templateKey
+	if !cacheOptions.Disabled {
+		if stack, err := describeStack(ctx, client, stackName); err == nil {
+			key = templateKeyFor(stack.StackId, stack.CreationTime, stack.LastUpdatedTime, stack.StackStatus)
+		}
+	}
+
+	body, err := getTemplateBody(ctx, client, stackName, key)
 	if err != nil {
-		fatalf("failed to get template for stack %q: %v\n", stackName, err)
+		return fmt.Errorf("failed to get template for stack %q: %w", stackName, err)
 	}
 
-	body := getValue(output.TemplateBody)
+	if isObjectOutput() {
+		template, err := parseTemplate(body)
+		if err != nil {
+			return fmt.Errorf("failed to convert template for stack %q: %w", stackName, err)
+		}
+		return printObject(template)
+	}
 
 	if pretty {
 		// Attempt JSON pretty-print; fall through to raw output if it's YAML.
@@ -48,10 +71,10 @@ func runTemplate(stackName string, pretty bool) {
 		if err := json.Unmarshal([]byte(body), &raw); err == nil {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetIndent("", "  ")
-			_ = enc.Encode(raw)
-			return
+			return enc.Encode(raw)
 		}
 	}
 
 	fmt.Print(body)
+	return nil
 }
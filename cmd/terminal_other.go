@@ -0,0 +1,11 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// ttySize is not implemented on this platform; $COLUMNS and $LINES are used
+// instead.
+func ttySize(*os.File) (width, height int) {
+	return 0, 0
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+const nestedStackType = "AWS::CloudFormation::Stack"
+
+// stackTree is the structured (--output json|yaml) form of `cfn tree`: a stack
+// with its resources, nested stacks expanded in place.
+type stackTree struct {
+	StackName   string
+	StackId     string
+	StackStatus string         `json:",omitempty"` // only for the root; nested stacks have their resource's status
+	Resources   []treeResource `json:",omitempty"`
+	Error       string         `json:",omitempty"` // why the resources could not be listed
+}
+
+type treeResource struct {
+	LogicalResourceId    string
+	PhysicalResourceId   string `json:",omitempty"`
+	ResourceType         string
+	ResourceStatus       types.ResourceStatus
+	ResourceStatusReason string     `json:",omitempty"`
+	LastUpdatedTimestamp *time.Time `json:",omitempty"`
+	Stack                *stackTree `json:",omitempty"`
+}
+
+func TreeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tree <stack-name>",
+		Short: "Show a stack's nested stacks and resources as a tree",
+		Long: `Show a stack's nested stacks and resources as a tree.
+
+Every AWS::CloudFormation::Stack resource is expanded recursively, so the whole
+application is shown with each resource's type, physical ID and status.
+Resources that are not in a *_COMPLETE state are highlighted, and counted on
+stderr.
+
+With --output name the names of the stack and all its nested stacks are
+printed, one per line.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTree(args[0])
+		},
+	}
+}
+
+func runTree(stackName string) error {
+	ctx := context.Background()
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	stack, err := describeStack(ctx, client, stackName)
+	if err != nil {
+		return err
+	}
+
+	tree := buildStackTree(ctx, client, getValue(stack.StackId), getValue(stack.StackName), map[string]bool{})
+	tree.StackStatus = string(stack.StackStatus)
+	if tree.Error != "" {
+		return fmt.Errorf("failed to list resources for stack %q: %s", stackName, tree.Error)
+	}
+
+	switch {
+	case isObjectOutput():
+		return printObject(tree)
+	case outputFormat == outputName:
+		printNames(tree.stackNames())
+		return nil
+	}
+	return printStackTree(tree)
+}
+
+// buildStackTree lists a stack's resources and recurses into nested stacks.
+// A nested stack that can't be listed keeps the error instead of failing the
+// whole tree. seen guards against walking a stack twice.
+func buildStackTree(ctx context.Context, client cloudFormationAPI, stackID, stackName string, seen map[string]bool) *stackTree {
+	tree := &stackTree{StackName: stackName, StackId: stackID}
+	seen[stackID] = true
+
+	resources, err := listStackResources(ctx, client, stackID)
+	if err != nil {
+		tree.Error = err.Error()
+		return tree
+	}
+
+	for _, r := range resources {
+		res := treeResource{
+			LogicalResourceId:    getValue(r.LogicalResourceId),
+			PhysicalResourceId:   getValue(r.PhysicalResourceId),
+			ResourceType:         getValue(r.ResourceType),
+			ResourceStatus:       r.ResourceStatus,
+			ResourceStatusReason: getValue(r.ResourceStatusReason),
+			LastUpdatedTimestamp: r.LastUpdatedTimestamp,
+		}
+		if res.ResourceType == nestedStackType && res.PhysicalResourceId != "" && !seen[res.PhysicalResourceId] {
+			res.Stack = buildStackTree(ctx, client, res.PhysicalResourceId, stackNameFromID(res.PhysicalResourceId), seen)
+		}
+		tree.Resources = append(tree.Resources, res)
+	}
+	return tree
+}
+
+func (t *stackTree) stackNames() []string {
+	names := []string{t.StackName}
+	for _, r := range t.Resources {
+		if r.Stack != nil {
+			names = append(names, r.Stack.stackNames()...)
+		}
+	}
+	return names
+}
+
+// needsAttention is true for statuses other than *_COMPLETE: in progress,
+// failed or skipped.
+func needsAttention(status string) bool {
+	return status != "" && !strings.HasSuffix(status, "_COMPLETE")
+}
+
+func printStackTree(tree *stackTree) error {
+	wide := isWideOutput()
+	columns := []string{"RESOURCE", "TYPE", "PHYSICAL ID", "STATUS"}
+	if wide {
+		columns = append(columns, "LAST UPDATED", "REASON")
+	}
+	table := makeTable(columns)
+	var highlight []bool
+
+	addRow := func(name, resType, physicalID, status string, updated *time.Time, reason string) {
+		cells := []interface{}{name, resType, physicalID, status}
+		if wide {
+			cells = append(cells, formatTime(updated), reason)
+		}
+		table.Rows = append(table.Rows, v1.TableRow{Cells: cells})
+		highlight = append(highlight, needsAttention(status))
+	}
+
+	var walk func(t *stackTree, indent string)
+	walk = func(t *stackTree, indent string) {
+		if t.Error != "" {
+			addRow(indent+"└─ (error)", "", "", "", nil, t.Error)
+			return
+		}
+		for i, r := range t.Resources {
+			branch, next := "├─ ", "│  "
+			if i == len(t.Resources)-1 {
+				branch, next = "└─ ", "   "
+			}
+			physicalID := r.PhysicalResourceId
+			if r.Stack != nil && !wide {
+				physicalID = r.Stack.StackName
+			}
+			addRow(indent+branch+r.LogicalResourceId, r.ResourceType, physicalID, string(r.ResourceStatus), r.LastUpdatedTimestamp, r.ResourceStatusReason)
+			if r.Stack != nil {
+				walk(r.Stack, indent+next)
+			}
+		}
+	}
+	addRow(tree.StackName, nestedStackType, tree.StackId, tree.StackStatus, nil, "")
+	if !wide {
+		table.Rows[0].Cells[2] = ""
+	}
+	walk(tree, "")
+
+	// Colour whole lines after the table is laid out, so escape codes don't
+	// throw off the column widths
+	var buf bytes.Buffer
+	printer := printers.NewTablePrinter(printers.PrintOptions{NoHeaders: noHeaders})
+	if err := printer.PrintObj(table, &buf); err != nil {
+		return fmt.Errorf("error printing table: %w", err)
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	offset := 1
+	if noHeaders {
+		offset = 0
+	}
+	color := colorEnabled(os.Stdout)
+	attention := 0
+	for i, line := range lines {
+		row := i - offset
+		if row >= 0 && row < len(highlight) && highlight[row] {
+			if row > 0 {
+				attention++
+			}
+			line = colorize(strings.TrimSuffix(line, "\n"), ansiRed, color) + "\n"
+		}
+		fmt.Print(line)
+	}
+
+	if attention > 0 {
+		fmt.Fprintf(os.Stderr, "%d resources are not in a *_COMPLETE state\n", attention)
+	}
+	return nil
+}
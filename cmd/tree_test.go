@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestTreeTable(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	var err error
+	var stderr string
+	out := captureStdout(t, func() {
+		stderr = captureStderr(t, func() { err = runTree("shop") })
+	})
+	if err != nil {
+		t.Fatalf("runTree: %v", err)
+	}
+
+	// The RESOURCE column ends where TYPE starts; the drawing is multi-byte
+	var resources []string
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	width := strings.Index(lines[0], "TYPE")
+	for _, line := range lines[1:] {
+		resources = append(resources, strings.TrimRight(string([]rune(line)[:width]), " "))
+	}
+	want := []string{
+		"shop",
+		"├─ Api",
+		"├─ Database",
+		"│  ├─ Cache",
+		"│  │  ├─ CacheCluster",
+		"│  │  └─ CacheParams",
+		"│  └─ Db",
+		"└─ Network",
+		"   └─ Vpc",
+	}
+	if strings.Join(resources, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected tree:\n%s", out)
+	}
+	if fields := columnGap.Split(lines[3], -1); fields[2] != "shop-Database-1A2B3C" {
+		t.Errorf("expected the nested stack name as physical ID, got %q", lines[3])
+	}
+	if stderr != "" {
+		t.Errorf("unexpected stderr %q", stderr)
+	}
+}
+
+func TestTreeHighlightsIncomplete(t *testing.T) {
+	fake := newNestedFakeClient(t)
+	setOutput(t, "")
+	cache, _ := fake.find(aws.String("shop-Database-1A2B3C-Cache-4D5E6F"))
+	cache.Resources[0].ResourceStatus = "UPDATE_FAILED"
+
+	var err error
+	var stderr string
+	out := captureStdout(t, func() {
+		stderr = captureStderr(t, func() { err = runTree("shop") })
+	})
+	if err != nil {
+		t.Fatalf("runTree: %v", err)
+	}
+	if !strings.Contains(out, "UPDATE_FAILED") {
+		t.Errorf("expected the failed resource in the tree:\n%s", out)
+	}
+	// Not a terminal, so no colour; the count still goes to stderr
+	if strings.Contains(out, ansiRed) {
+		t.Errorf("unexpected colour codes:\n%q", out)
+	}
+	if stderr != "1 resources are not in a *_COMPLETE state\n" {
+		t.Errorf("unexpected stderr %q", stderr)
+	}
+}
+
+func TestTreeNames(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "name")
+
+	var err error
+	out := captureStdout(t, func() { err = runTree("shop") })
+	if err != nil {
+		t.Fatalf("runTree: %v", err)
+	}
+	want := "shop\nshop-Database-1A2B3C\nshop-Database-1A2B3C-Cache-4D5E6F\nshop-Network-7G8H9I\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestTreeJSON(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "json")
+
+	var err error
+	out := captureStdout(t, func() { err = runTree("shop") })
+	if err != nil {
+		t.Fatalf("runTree: %v", err)
+	}
+	var tree stackTree
+	if err := json.Unmarshal([]byte(out), &tree); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if tree.StackStatus != "UPDATE_ROLLBACK_COMPLETE" || len(tree.Resources) != 3 {
+		t.Fatalf("unexpected root %+v", tree)
+	}
+	db := tree.Resources[1].Stack
+	if db == nil || db.StackName != "shop-Database-1A2B3C" || db.Resources[0].Stack == nil {
+		t.Errorf("expected Database and its Cache stack to be expanded, got %+v", db)
+	}
+}
+
+func TestTreeStackNotFound(t *testing.T) {
+	newNestedFakeClient(t)
+	setOutput(t, "")
+
+	var err error
+	captureStdout(t, func() { err = runTree("missing") })
+	if ExitCode(err) != exitNotFound {
+		t.Errorf("got exit code %d (%v), want %d", ExitCode(err), err, exitNotFound)
+	}
+}
@@ -7,36 +7,71 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// validation is the structured (--output json|yaml) form of a template validation.
+type validation struct {
+	Description        *string
+	Parameters         []types.TemplateParameter
+	Capabilities       []types.Capability
+	CapabilitiesReason *string
+	DeclaredTransforms []string
+}
+
+func (v validation) listItems() []any { return toItems(v.Parameters) }
+
 func ValidateCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "validate <template-file>",
 		Short: "Validate a CloudFormation template file",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			runValidate(args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(args[0])
 		},
 	}
 }
 
-func runValidate(templateFile string) {
+func runValidate(templateFile string) error {
 	data, err := os.ReadFile(templateFile)
 	if err != nil {
-		fatalf("failed to read template file %q: %v\n", templateFile, err)
+		return fmt.Errorf("failed to read template file %q: %w", templateFile, err)
 	}
 
 	ctx := context.Background()
-	client := mustClient(ctx)
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	body := string(data)
 	output, err := client.ValidateTemplate(ctx, &cloudformation.ValidateTemplateInput{
 		TemplateBody: &body,
 	})
 	if err != nil {
-		fatalf("template validation failed: %v\n", err)
+		// Auth and throttling failures keep their own exit codes
+		if ExitCode(err) == exitError {
+			return withExitCode(exitValidationFailed, fmt.Errorf("template validation failed: %w", err))
+		}
+		return fmt.Errorf("template validation failed: %w", err)
+	}
+
+	switch {
+	case isObjectOutput():
+		return printObject(validation{
+			Description:        output.Description,
+			Parameters:         append([]types.TemplateParameter{}, output.Parameters...),
+			Capabilities:       append([]types.Capability{}, output.Capabilities...),
+			CapabilitiesReason: output.CapabilitiesReason,
+			DeclaredTransforms: append([]string{}, output.DeclaredTransforms...),
+		})
+	case outputFormat == outputName:
+		for _, p := range output.Parameters {
+			fmt.Println(getValue(p.ParameterKey))
+		}
+		return nil
 	}
 
 	fmt.Println("Template is valid ✓")
@@ -58,7 +93,9 @@ func runValidate(templateFile string) {
 				},
 			})
 		}
-		mustPrint(table)
+		if err := printTable(table); err != nil {
+			return err
+		}
 	}
 
 	if len(output.Capabilities) > 0 {
@@ -75,4 +112,5 @@ func runValidate(templateFile string) {
 	if output.CapabilitiesReason != nil && *output.CapabilitiesReason != "" {
 		fmt.Printf("Capabilities Reason: %s\n", *output.CapabilitiesReason)
 	}
+	return nil
 }
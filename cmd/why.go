@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/spf13/cobra"
+)
+
+// whyResult is the structured (--output json|yaml) form of `cfn why`.
+type whyResult struct {
+	StackName      string
+	StackStatus    types.StackStatus
+	Operation      string     `json:",omitempty"`
+	OperationStart *time.Time `json:",omitempty"`
+	RootCauses     []rootCause
+}
+
+func (r whyResult) listItems() []any { return toItems(r.RootCauses) }
+
+// rootCause is a failure event, with the logical IDs leading to it from the
+// stack that was asked about, e.g. [app Database Cache CacheCluster].
+type rootCause struct {
+	Path []string
+	types.StackEvent
+}
+
+func WhyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "why <stack-name>",
+		Short: "Explain why the last operation on a stack failed",
+		Long: `Explain why the last operation on a stack failed.
+
+The events of the most recent operation, starting from the last "User
+Initiated" event, are searched for the earliest failures, ignoring
+"Resource ... cancelled" events caused by other failures and failures
+during the rollback. Failures of nested stacks are followed down to the
+failing resource, so the real cause is shown instead of "Embedded stack ...
+was not successfully updated".
+
+With --output name the logical IDs of the failed resources are printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWhy(args[0])
+		},
+	}
+}
+
+func runWhy(stackName string) error {
+	ctx := context.Background()
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	stack, err := describeStack(ctx, client, stackName)
+	if err != nil {
+		return err
+	}
+	stackID := getValue(stack.StackId)
+
+	events, err := listEventsUntil(ctx, client, stackID, isOperationStart)
+	if err != nil {
+		return fmt.Errorf("failed to list events for stack %q: %w", stackName, err)
+	}
+
+	result := whyResult{StackName: getValue(stack.StackName), StackStatus: stack.StackStatus}
+	var since time.Time
+	if n := len(events); n > 0 && isOperationStart(events[n-1]) {
+		result.Operation = operationKind(events[n-1])
+		result.OperationStart = events[n-1].Timestamp
+		since = eventTime(events[n-1])
+	}
+
+	seen := map[string]bool{stackID: true}
+	result.RootCauses = findRootCauses(ctx, client, events, since, []string{result.StackName}, seen)
+	if len(result.RootCauses) == 0 {
+		// Nothing failed on a resource, e.g. a template or parameter error:
+		// the stack's own events carry the reason
+		if e, ok := stackFailure(events); ok {
+			result.RootCauses = []rootCause{{Path: []string{result.StackName}, StackEvent: e}}
+		}
+	}
+
+	switch {
+	case isObjectOutput():
+		return printObject(result)
+	case outputFormat == outputName:
+		for _, c := range result.RootCauses {
+			fmt.Println(getValue(c.LogicalResourceId))
+		}
+		return nil
+	}
+	printWhy(result)
+	return nil
+}
+
+// findRootCauses returns the failures of one stack's operation, oldest first,
+// replacing failed nested stacks with their own root causes. events are the
+// stack's events newest first; nested stacks are read back to since.
+func findRootCauses(ctx context.Context, client cloudFormationAPI, events []types.StackEvent, since time.Time, path []string, seen map[string]bool) []rootCause {
+	var causes []rootCause
+	for _, e := range operationFailures(events) {
+		p := append(append([]string{}, path...), getValue(e.LogicalResourceId))
+
+		if id := nestedStackID(e); id != "" && !seen[id] {
+			seen[id] = true
+			nested, err := listEventsSince(ctx, client, id, since)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to list events for nested stack %q: %v\n", stackNameFromID(id), err)
+			} else if deeper := findRootCauses(ctx, client, nested, since, p, seen); len(deeper) > 0 {
+				causes = append(causes, deeper...)
+				continue
+			}
+		}
+		causes = append(causes, rootCause{Path: p, StackEvent: e})
+	}
+	return causes
+}
+
+// operationFailures picks the resource failures worth explaining from one
+// stack's events (newest first) and returns them oldest first. Cancellations
+// are a consequence of other failures and are skipped, as are failures after
+// the rollback started, unless those are the only ones.
+func operationFailures(events []types.StackEvent) []types.StackEvent {
+	var failures []types.StackEvent
+	var rollback time.Time
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		status := string(e.ResourceStatus)
+		switch {
+		case isStackEvent(e):
+			if rollback.IsZero() && strings.Contains(status, "ROLLBACK_IN_PROGRESS") {
+				rollback = eventTime(e)
+			}
+		case strings.HasSuffix(status, "_FAILED") && !isCancellation(e):
+			failures = append(failures, e)
+		}
+	}
+
+	if rollback.IsZero() {
+		return failures
+	}
+	var beforeRollback []types.StackEvent
+	for _, e := range failures {
+		if !eventTime(e).After(rollback) {
+			beforeRollback = append(beforeRollback, e)
+		}
+	}
+	if len(beforeRollback) == 0 {
+		return failures
+	}
+	return beforeRollback
+}
+
+// isCancellation matches "Resource creation cancelled", "Resource update
+// cancelled" and the like.
+func isCancellation(e types.StackEvent) bool {
+	reason := getValue(e.ResourceStatusReason)
+	return strings.HasPrefix(reason, "Resource ") && strings.HasSuffix(reason, " cancelled")
+}
+
+// stackFailure returns the stack's own earliest failed or rollback event with
+// a reason, from events newest first.
+func stackFailure(events []types.StackEvent) (types.StackEvent, bool) {
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		status := string(e.ResourceStatus)
+		if isStackEvent(e) && getValue(e.ResourceStatusReason) != "" &&
+			(strings.HasSuffix(status, "_FAILED") || strings.Contains(status, "ROLLBACK_IN_PROGRESS")) {
+			return e, true
+		}
+	}
+	return types.StackEvent{}, false
+}
+
+func printWhy(r whyResult) {
+	operation := "last operation"
+	if r.Operation != "" {
+		operation = fmt.Sprintf("%s started at %s", r.Operation, formatTime(r.OperationStart))
+	}
+
+	if len(r.RootCauses) == 0 {
+		fmt.Printf("Stack %q is %s; nothing failed in the %s.\n", r.StackName, r.StackStatus, operation)
+		return
+	}
+
+	fmt.Printf("Stack %q is %s after the %s.\n\n", r.StackName, r.StackStatus, operation)
+	printRootCauses(os.Stdout, r.RootCauses)
+}
+
+func printRootCauses(w io.Writer, causes []rootCause) {
+	if len(causes) == 1 {
+		fmt.Fprintln(w, "Root cause:")
+	} else {
+		fmt.Fprintln(w, "Root causes:")
+	}
+	for i, c := range causes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "  %s (%s)\n", strings.Join(c.Path, " › "), getValue(c.ResourceType))
+		fmt.Fprintf(w, "  %s at %s\n", c.ResourceStatus, formatTime(c.Timestamp))
+		if reason := getValue(c.ResourceStatusReason); reason != "" {
+			fmt.Fprintf(w, "  %s\n", reason)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func runWhyOutput(t *testing.T, format, stackName string) string {
+	t.Helper()
+	newNestedFakeClient(t)
+	setOutput(t, format)
+
+	var err error
+	out := captureStdout(t, func() { err = runWhy(stackName) })
+	if err != nil {
+		t.Fatalf("runWhy: %v", err)
+	}
+	return out
+}
+
+func TestWhyFollowsNestedStacks(t *testing.T) {
+	out := runWhyOutput(t, "", "shop")
+
+	for _, want := range []string{
+		`Stack "shop" is UPDATE_ROLLBACK_COMPLETE after the update started at 2024-09-10 14:00:00.`,
+		"Root cause:",
+		"shop › Database › Cache › CacheCluster (AWS::ElastiCache::CacheCluster)",
+		"UPDATE_FAILED at 2024-09-10 14:01:20",
+		"Invalid node type: cache.t9.micro",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	// The cancelled Db update is a consequence, not a cause
+	if strings.Contains(out, "Db") {
+		t.Errorf("unexpected cancelled resource in:\n%s", out)
+	}
+}
+
+func TestWhyJSON(t *testing.T) {
+	var result whyResult
+	if err := json.Unmarshal([]byte(runWhyOutput(t, "json", "shop")), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if result.Operation != "update" || len(result.RootCauses) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if got, want := result.RootCauses[0].Path, []string{"shop", "Database", "Cache", "CacheCluster"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got path %v, want %v", got, want)
+	}
+	if got := getValue(result.RootCauses[0].StackName); got != "shop-Database-1A2B3C-Cache-4D5E6F" {
+		t.Errorf("got stack %q", got)
+	}
+}
+
+func TestWhySucceeded(t *testing.T) {
+	out := runWhyOutput(t, "", "prod-app-api")
+	if !strings.Contains(out, "nothing failed in the update started at 2024-06-15 08:30:00") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if out := runWhyOutput(t, "name", "prod-app-api"); out != "" {
+		t.Errorf("expected no names, got %q", out)
+	}
+}
+
+func TestOperationFailures(t *testing.T) {
+	const stackID = "arn:aws:cloudformation:us-east-1:111111111111:stack/app/1"
+	event := func(id string, minute int, status, reason string, self bool) types.StackEvent {
+		ts := time.Date(2024, 6, 15, 8, minute, 0, 0, time.UTC)
+		e := types.StackEvent{
+			EventId:              aws.String(id),
+			StackId:              aws.String(stackID),
+			ResourceStatus:       types.ResourceStatus(status),
+			ResourceStatusReason: aws.String(reason),
+			Timestamp:            &ts,
+		}
+		if self {
+			e.PhysicalResourceId = aws.String(stackID)
+			e.ResourceType = aws.String(nestedStackType)
+		}
+		return e
+	}
+
+	// Newest first, as returned by DescribeStackEvents
+	events := []types.StackEvent{
+		event("rollback-failed", 5, "DELETE_FAILED", "bucket not empty", false),
+		event("rollback", 3, "UPDATE_ROLLBACK_IN_PROGRESS", "The following resource(s) failed to update: [B].", true),
+		event("cancelled", 2, "UPDATE_FAILED", "Resource update cancelled", false),
+		event("b", 2, "UPDATE_FAILED", "access denied", false),
+		event("a", 1, "UPDATE_FAILED", "invalid value", false),
+		event("start", 0, "UPDATE_IN_PROGRESS", "User Initiated", true),
+	}
+	if got, want := eventIDs(operationFailures(events)), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Only failures during the rollback: those are the ones to explain
+	if got, want := eventIDs(operationFailures(events[:2])), []string{"rollback-failed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
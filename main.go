@@ -9,22 +9,52 @@ import (
 )
 
 var (
-	region    string
-	noHeaders bool
+	region     string
+	noHeaders  bool
+	output     string
+	color      string
+	awsOptions cmd.AWSOptions
+	cache      cmd.CacheOptions
 )
 
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "cfn",
 		Short: "AWS CloudFormation CLI tool",
-		Long:  "Inspect and manage AWS CloudFormation stacks",
-		PersistentPreRun: func(command *cobra.Command, args []string) {
-			cmd.SetGlobalFlags(region, noHeaders)
+		Long: `Inspect and manage AWS CloudFormation stacks
+
+Exit codes:
+  0  success
+  1  general error or invalid usage
+  2  stack not found
+  3  no stacks or resources matched
+  4  drift detected
+  5  template validation failed
+  6  authentication or authorization error
+  7  throttled by AWS
+  8  stack operation failed or rolled back (tail --until-complete)
+  9  timed out waiting for a stack operation (tail --until-complete)`,
+		SilenceErrors: true,
+		PersistentPreRunE: func(command *cobra.Command, args []string) error {
+			// Flags and arguments are valid at this point; runtime errors
+			// shouldn't be followed by the usage text.
+			command.SilenceUsage = true
+			return cmd.SetGlobalFlags(region, noHeaders, output, color, awsOptions, cache)
 		},
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&region, "region", "r", "", "AWS region (uses default if not specified)")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.Profile, "profile", "", "AWS named profile (uses AWS_PROFILE or default if not specified)")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.RoleARN, "role-arn", "", "IAM role to assume before calling CloudFormation")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.ExternalID, "external-id", "", "External ID to use when assuming --role-arn")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.SessionName, "session-name", "", "Session name to use when assuming --role-arn")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.MFASerial, "mfa-serial", "", "MFA device serial number or ARN; prompts for a token code when assuming --role-arn")
+	rootCmd.PersistentFlags().StringVar(&awsOptions.EndpointURL, "endpoint-url", "", "Custom CloudFormation endpoint URL (e.g. LocalStack)")
+	rootCmd.PersistentFlags().BoolVar(&cache.Disabled, "no-cache", false, "Don't read or write the local template cache")
+	rootCmd.PersistentFlags().BoolVar(&cache.Refresh, "refresh", false, "Refetch templates and update the local template cache")
 	rootCmd.PersistentFlags().BoolVar(&noHeaders, "no-headers", false, "Don't print headers")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "Output format: json|yaml|wide|name|go-template=...|go-template-file=...|jsonpath=...|jsonpath-file=...|custom-columns=... (default is a table)")
+	rootCmd.PersistentFlags().StringVar(&color, "color", "auto", "Colour output: always|never|auto (auto colours a terminal unless NO_COLOR is set)")
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	rootCmd.AddCommand(
@@ -33,15 +63,18 @@ func main() {
 		cmd.DescribeCmd(),
 		cmd.OutputsCmd(),
 		cmd.ResourcesCmd(),
+		cmd.TreeCmd(),
 		cmd.DriftCmd(),
 		cmd.TailCmd(),
+		cmd.WhyCmd(),
 		cmd.TemplateCmd(),
 		cmd.ValidateCmd(),
+		cmd.CacheCmd(),
 		cmd.GenDocsCmd(rootCmd),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }